@@ -10,16 +10,19 @@ package adapter
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	badger "github.com/dgraph-io/badger/v4"
 	"github.com/gogf/gf/v2/container/gmap"
 	"github.com/gogf/gf/v2/container/gvar"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gcache"
-	"github.com/gogf/gf/v2/os/gtime"
 	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/grand"
 	"github.com/tiger1103/gfast-cache/instance"
+	"github.com/tiger1103/gfast-cache/internal/singleflight"
 	"reflect"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -30,6 +33,8 @@ const (
 	// defaultMaxExpire is the default expire time for no expiring items.
 	// It equals to math.MaxInt64/1000000.
 	defaultMaxExpire time.Duration = 9223372036854
+	// distMaxRetries 事务冲突时的最大重试次数
+	distMaxRetries = 100
 )
 
 var (
@@ -107,11 +112,17 @@ type Dist struct {
 	config *Config
 	db     *badger.DB
 	mu     sync.RWMutex
+	flight singleflight.Group // 合并同一个键的GetOrSetFuncLock
 }
 
 func (d *Dist) Set(ctx context.Context, key interface{}, value interface{}, duration time.Duration) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	return d.set(key, value, duration)
+}
+
+// set 写入缓存，调用者需持有写锁
+func (d *Dist) set(key interface{}, value interface{}, duration time.Duration) error {
 	duration = d.getInternalExpire(duration)
 	err := d.db.Update(func(txn *badger.Txn) (err error) {
 		value, err = d.convertOptionToArgs(value)
@@ -125,13 +136,63 @@ func (d *Dist) Set(ctx context.Context, key interface{}, value interface{}, dura
 }
 
 func (d *Dist) SetMap(ctx context.Context, data map[interface{}]interface{}, duration time.Duration) error {
+	items := make([]BatchItem, 0, len(data))
 	for k, v := range data {
-		err := d.Set(ctx, k, v, duration)
+		items = append(items, BatchItem{Key: k, Value: v, Duration: duration})
+	}
+	return d.SetBatch(ctx, items)
+}
+
+// BatchItem is a key-value pair written by SetBatch, which expires after <Duration>.
+type BatchItem struct {
+	Key      interface{}
+	Value    interface{}
+	Duration time.Duration
+}
+
+// SetBatch writes <items> in one write batch, each of them expires after its own duration.
+func (d *Dist) SetBatch(ctx context.Context, items []BatchItem) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	wb := d.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, item := range items {
+		value, err := d.convertOptionToArgs(item.Value)
 		if err != nil {
 			return err
 		}
+		e := badger.NewEntry(gconv.Bytes(item.Key), gconv.Bytes(value)).WithTTL(d.getInternalExpire(item.Duration))
+		if err = wb.SetEntry(e); err != nil {
+			return err
+		}
 	}
-	return nil
+	return wb.Flush()
+}
+
+// GetMany returns the values of <keys> in one transaction, the keys which do not exist
+// are not in the result.
+func (d *Dist) GetMany(ctx context.Context, keys []string) (map[string]*gvar.Var, error) {
+	values := make(map[string]*gvar.Var, len(keys))
+	err := d.db.View(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			item, err := txn.Get([]byte(key))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			err = item.Value(func(val []byte) error {
+				values[key] = gvar.New(append([]byte{}, val...))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return values, err
 }
 
 func (d *Dist) SetIfNotExist(ctx context.Context, key interface{}, value interface{}, duration time.Duration) (ok bool, err error) {
@@ -168,10 +229,10 @@ func (d *Dist) SetIfNotExistFunc(ctx context.Context, key interface{}, f gcache.
 	return true, nil
 }
 
+// SetIfNotExistFuncLock works like SetIfNotExistFunc, but the concurrent calls of the same
+// key execute <f> only once, see loadLock.
 func (d *Dist) SetIfNotExistFuncLock(ctx context.Context, key interface{}, f gcache.Func, duration time.Duration) (ok bool, err error) {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-	ok, err = d.SetIfNotExistFunc(ctx, key, f, duration)
+	_, ok, err = d.loadLock(ctx, key, f, duration)
 	return
 }
 
@@ -179,23 +240,23 @@ func (d *Dist) Get(ctx context.Context, key interface{}) (value *gvar.Var, err e
 	err = d.db.View(func(txn *badger.Txn) error {
 		item, e := txn.Get(gconv.Bytes(key))
 		if e != nil {
-			g.Log().Error(ctx, e)
-			return nil
-		}
-		if item != nil {
-			err = item.Value(func(val []byte) error {
-				value = gvar.New(val)
+			// 键不存在时返回nil
+			if errors.Is(e, badger.ErrKeyNotFound) {
 				return nil
-			})
+			}
+			return e
 		}
-		return err
+		return item.Value(func(val []byte) error {
+			// val只在事务内有效，复制一份；空值也返回非nil，与键不存在区分
+			value = gvar.New(append([]byte{}, val...))
+			return nil
+		})
 	})
 	return
 }
 
 func (d *Dist) GetOrSet(ctx context.Context, key interface{}, value interface{}, duration time.Duration) (result *gvar.Var, err error) {
-	result, _ = d.Get(ctx, key)
-	if !result.IsEmpty() {
+	if result, err = d.Get(ctx, key); err != nil || !result.IsNil() {
 		return
 	}
 	result = gvar.New(value)
@@ -203,20 +264,64 @@ func (d *Dist) GetOrSet(ctx context.Context, key interface{}, value interface{},
 	return
 }
 
+// GetOrSetFunc returns the value of <key>, or sets <key> with the result of <f> and returns
+// it if <key> does not exist. Like the memory adapter, nothing is written if <f> returns an
+// error or nil, so that the next call executes <f> again.
 func (d *Dist) GetOrSetFunc(ctx context.Context, key interface{}, f gcache.Func, duration time.Duration) (result *gvar.Var, err error) {
-	result, _ = d.Get(ctx, key)
-	if !result.IsEmpty() {
+	if result, err = d.Get(ctx, key); err != nil || !result.IsNil() {
 		return
 	}
 	var value interface{}
 	value, err = f(ctx)
+	if err != nil || value == nil {
+		return nil, err
+	}
 	result = gvar.New(value)
 	err = d.Set(ctx, key, value, duration)
 	return
 }
 
+// GetOrSetFuncLock works like GetOrSetFunc, but the concurrent calls of the same key
+// execute <f> only once, see loadLock.
 func (d *Dist) GetOrSetFuncLock(ctx context.Context, key interface{}, f gcache.Func, duration time.Duration) (result *gvar.Var, err error) {
-	return d.GetOrSetFunc(ctx, key, f, duration)
+	result, _, err = d.loadLock(ctx, key, f, duration)
+	return
+}
+
+// loadLock returns the value of <key>, or executes <f> and writes its result if the key
+// does not exist. The concurrent calls of the same key are deduplicated, so that <f> is
+// executed once per key, and it is executed outside the writing lock which is only held
+// around the write, so that a slow <f> does not block the other keys. The returned
+// <stored> reports whether the result of <f> is written by this call.
+func (d *Dist) loadLock(ctx context.Context, key interface{}, f gcache.Func, duration time.Duration) (result *gvar.Var, stored bool, err error) {
+	if result, err = d.Get(ctx, key); err != nil || !result.IsNil() {
+		return
+	}
+	v, err, _ := d.flight.Do(gconv.String(key), func() (interface{}, error) {
+		// 等待期间可能已被其它调用写入
+		if existing, err := d.Get(ctx, key); err != nil || !existing.IsNil() {
+			return existing, err
+		}
+		value, err := f(ctx)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		// 执行f期间键可能被Set写入，不覆盖
+		if existing, err := d.Get(ctx, key); err != nil || !existing.IsNil() {
+			return existing, err
+		}
+		if err = d.set(key, value, duration); err != nil {
+			return nil, err
+		}
+		stored = true
+		return gvar.New(value), nil
+	})
+	if v != nil {
+		result = v.(*gvar.Var)
+	}
+	return
 }
 
 func (d *Dist) Contains(ctx context.Context, key interface{}) (b bool, err error) {
@@ -225,7 +330,7 @@ func (d *Dist) Contains(ctx context.Context, key interface{}) (b bool, err error
 	if err != nil {
 		return
 	}
-	b = !val.IsEmpty()
+	b = !val.IsNil()
 	return
 }
 
@@ -254,8 +359,7 @@ func (d *Dist) Data(ctx context.Context) (data map[interface{}]interface{}, err
 			item := it.Item()
 			k := item.Key()
 			err = item.Value(func(v []byte) error {
-				fmt.Printf("键值对的值分别是:%s-%s\n", k, v)
-				data[gconv.String(k)] = v
+				data[gconv.String(k)] = append([]byte(nil), v...)
 				return nil
 			})
 			if err != nil {
@@ -275,16 +379,61 @@ func (d *Dist) Keys(ctx context.Context) (keys []interface{}, err error) {
 		opts.PrefetchValues = false
 		it := txn.NewIterator(opts)
 		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			// 迭代器复用key的内存，需要复制
+			keys = append(keys, string(it.Item().Key()))
+		}
+		return nil
+	})
+	return
+}
+
+// KeysWithPrefix returns all keys starting with <prefix>.
+func (d *Dist) KeysWithPrefix(ctx context.Context, prefix string) (keys []string, err error) {
+	err = d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, string(it.Item().Key()))
+		}
+		return nil
+	})
+	return
+}
+
+// DataWithPrefix returns a copy of all key-value pairs whose key starts with <prefix>.
+func (d *Dist) DataWithPrefix(ctx context.Context, prefix string) (data map[string][]byte, err error) {
+	data = make(map[string][]byte)
+	err = d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
 		for it.Rewind(); it.Valid(); it.Next() {
 			item := it.Item()
-			k := item.Key()
-			keys = append(keys, k)
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			data[string(item.Key())] = v
 		}
 		return nil
 	})
 	return
 }
 
+// RemoveWithPrefix deletes all keys starting with <prefix>. The <prefix> must not be empty,
+// use Clear to delete all keys.
+func (d *Dist) RemoveWithPrefix(ctx context.Context, prefix string) error {
+	if prefix == "" {
+		return errors.New("prefix must not be empty")
+	}
+	return d.db.DropPrefix([]byte(prefix))
+}
+
 func (d *Dist) Values(ctx context.Context) (values []interface{}, err error) {
 	values = make([]interface{}, 0, 1000)
 	err = d.db.View(func(txn *badger.Txn) error {
@@ -294,7 +443,7 @@ func (d *Dist) Values(ctx context.Context) (values []interface{}, err error) {
 		for it.Rewind(); it.Valid(); it.Next() {
 			item := it.Item()
 			err = item.Value(func(v []byte) error {
-				values = append(values, v)
+				values = append(values, append([]byte(nil), v...))
 				return nil
 			})
 			if err != nil {
@@ -307,10 +456,10 @@ func (d *Dist) Values(ctx context.Context) (values []interface{}, err error) {
 }
 
 func (d *Dist) Update(ctx context.Context, key interface{}, value interface{}) (oldValue *gvar.Var, exist bool, err error) {
-	oldValue, _ = d.Get(ctx, key)
-	if !oldValue.IsEmpty() {
-		exist = true
+	if oldValue, err = d.Get(ctx, key); err != nil {
+		return
 	}
+	exist = !oldValue.IsNil()
 	var duration time.Duration
 	duration, err = d.GetExpire(ctx, key)
 	if err != nil {
@@ -327,9 +476,7 @@ func (d *Dist) UpdateExpire(ctx context.Context, key interface{}, duration time.
 		if err != nil {
 			return err
 		}
-		expire := gtime.NewFromTimeStamp(gconv.Int64(item.ExpiresAt()))
-		now := gtime.Now()
-		oldDuration = gconv.Duration(expire.Sub(now))
+		oldDuration = remainingTTL(item)
 		err = item.Value(func(val []byte) error {
 			duration = d.getInternalExpire(duration)
 			e := badger.NewEntry(gconv.Bytes(key), val).WithTTL(duration)
@@ -348,9 +495,7 @@ func (d *Dist) GetExpire(ctx context.Context, key interface{}) (duration time.Du
 		if err != nil {
 			return err
 		}
-		expire := gtime.NewFromTimeStamp(gconv.Int64(item.ExpiresAt()))
-		now := gtime.Now()
-		duration = gconv.Duration(expire.Sub(now))
+		duration = remainingTTL(item)
 		return nil
 	})
 	return
@@ -361,14 +506,16 @@ func (d *Dist) Remove(ctx context.Context, keys ...interface{}) (lastValue *gvar
 		for index, key := range keys {
 			if index == len(keys)-1 {
 				item, err := txn.Get(gconv.Bytes(key))
-				if err != nil {
-					return err
-				}
-				err = item.Value(func(val []byte) error {
-					lastValue = gvar.New(val)
-					return nil
-				})
-				if err != nil {
+				switch {
+				case err == nil:
+					err = item.Value(func(val []byte) error {
+						lastValue = gvar.New(append([]byte{}, val...))
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+				case !errors.Is(err, badger.ErrKeyNotFound):
 					return err
 				}
 			}
@@ -377,11 +524,95 @@ func (d *Dist) Remove(ctx context.Context, keys ...interface{}) (lastValue *gvar
 				return err
 			}
 		}
-		return nil
+		// 键为哈希时同时删除其字段
+		return d.deleteFields(txn, keys)
+	})
+	return
+}
+
+// IncrBy increments the integer value of <key> by <delta> and returns the new value. The
+// <key> is created with <delta> if it does not exist, which expires after <duration>, and the
+// existing <key> keeps its expiry. It is updated in a transaction retried on conflicts.
+func (d *Dist) IncrBy(ctx context.Context, key interface{}, delta int64, duration time.Duration) (value int64, err error) {
+	err = d.incr(key, duration, func(old []byte) ([]byte, error) {
+		value = delta
+		if old != nil {
+			n, err := strconv.ParseInt(string(old), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf(`value of key "%v" is not an integer: %w`, key, err)
+			}
+			value += n
+		}
+		return []byte(strconv.FormatInt(value, 10)), nil
 	})
 	return
 }
 
+// IncrByFloat increments the float value of <key> by <delta> and returns the new value.
+// It works like IncrBy.
+func (d *Dist) IncrByFloat(ctx context.Context, key interface{}, delta float64, duration time.Duration) (value float64, err error) {
+	err = d.incr(key, duration, func(old []byte) ([]byte, error) {
+		value = delta
+		if old != nil {
+			n, err := strconv.ParseFloat(string(old), 64)
+			if err != nil {
+				return nil, fmt.Errorf(`value of key "%v" is not a float: %w`, key, err)
+			}
+			value += n
+		}
+		return []byte(strconv.FormatFloat(value, 'f', -1, 64)), nil
+	})
+	return
+}
+
+// incr 在事务中更新键的值，old为nil表示键不存在
+func (d *Dist) incr(key interface{}, duration time.Duration, f func(old []byte) ([]byte, error)) error {
+	k := gconv.Bytes(key)
+	return d.update(func(txn *badger.Txn) error {
+		var (
+			old       []byte
+			expiresAt uint64
+		)
+		item, err := txn.Get(k)
+		switch {
+		case err == nil:
+			expiresAt = item.ExpiresAt()
+			if old, err = item.ValueCopy(nil); err != nil {
+				return err
+			}
+			// 空值与不存在区分
+			if old == nil {
+				old = []byte{}
+			}
+		case !errors.Is(err, badger.ErrKeyNotFound):
+			return err
+		}
+		value, err := f(old)
+		if err != nil {
+			return err
+		}
+		e := badger.NewEntry(k, value)
+		if old != nil {
+			e.ExpiresAt = expiresAt
+		} else {
+			e = e.WithTTL(d.getInternalExpire(max(duration, 0)))
+		}
+		return txn.SetEntry(e)
+	})
+}
+
+// update 执行读写事务，事务冲突时重试
+func (d *Dist) update(f func(txn *badger.Txn) error) error {
+	for i := 0; ; i++ {
+		err := d.db.Update(f)
+		if !errors.Is(err, badger.ErrConflict) || i >= distMaxRetries {
+			return err
+		}
+		// 随机退避，避免并发的事务再次冲突
+		time.Sleep(time.Duration(grand.N(1, min(i+1, 10))) * time.Millisecond)
+	}
+}
+
 func (d *Dist) Clear(ctx context.Context) error {
 	err := d.db.DropAll()
 	return err
@@ -392,6 +623,15 @@ func (d *Dist) Close(ctx context.Context) error {
 	return err
 }
 
+// remainingTTL returns the remaining time to live of <item>. The expiring timestamp of badger
+// is in seconds, and the items without it are regarded as the ones which do not expire.
+func remainingTTL(item *badger.Item) time.Duration {
+	if item.ExpiresAt() == 0 {
+		return defaultMaxExpire * time.Millisecond
+	}
+	return time.Until(time.Unix(int64(item.ExpiresAt()), 0))
+}
+
 // getInternalExpire converts and returns the expiration time with given expired duration in milliseconds.
 func (d *Dist) getInternalExpire(duration time.Duration) time.Duration {
 	if duration == 0 {
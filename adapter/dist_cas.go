@@ -0,0 +1,70 @@
+/*
+* @desc:磁盘缓存的比较并交换，使用badger的条目版本
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 02:40
+ */
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// GetWithVersion returns the value of <key> and its version, which is the commit timestamp
+// of badger and changed by every write of <key>. It returns nil and 0 if <key> does not exist.
+func (d *Dist) GetWithVersion(ctx context.Context, key interface{}) (value *gvar.Var, version uint64, err error) {
+	err = d.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(gconv.Bytes(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		v, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		value, version = gvar.New(v), item.Version()
+		return nil
+	})
+	return
+}
+
+// CompareAndSet sets <key> to <value> if its version is still <expected>, which expires
+// after <duration>. The <expected> 0 means that <key> does not exist.
+func (d *Dist) CompareAndSet(ctx context.Context, key interface{}, expected uint64, value interface{}, duration time.Duration) (ok bool, err error) {
+	value, err = d.convertOptionToArgs(value)
+	if err != nil {
+		return false, err
+	}
+	k := gconv.Bytes(key)
+	err = d.update(func(txn *badger.Txn) error {
+		ok = false
+		var version uint64
+		item, err := txn.Get(k)
+		switch {
+		case err == nil:
+			version = item.Version()
+		case !errors.Is(err, badger.ErrKeyNotFound):
+			return err
+		}
+		if version != expected {
+			return nil
+		}
+		e := badger.NewEntry(k, gconv.Bytes(value)).WithTTL(d.getInternalExpire(max(duration, 0)))
+		if err = txn.SetEntry(e); err != nil {
+			return err
+		}
+		ok = true
+		return nil
+	})
+	return
+}
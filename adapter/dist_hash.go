@@ -0,0 +1,199 @@
+/*
+* @desc:磁盘缓存的哈希，每个字段保存为一个子键
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 23:50
+ */
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// HashFieldSep separates the key of a hash and the field name in the key of the field,
+// the field <field> of the hash <key> is saved as the key <key> + HashFieldSep + <field>.
+const HashFieldSep = "\x00"
+
+// hashFieldPrefix 哈希字段子键的前缀
+func hashFieldPrefix(key string) []byte {
+	return []byte(key + HashFieldSep)
+}
+
+// scanFields 遍历哈希的字段，迭代器复用item，f中需要复制使用
+func (d *Dist) scanFields(txn *badger.Txn, key string, prefetch bool, f func(field string, item *badger.Item) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = prefetch
+	opts.Prefix = hashFieldPrefix(key)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		if err := f(string(item.Key()[len(opts.Prefix):]), item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HSet sets the <fields> of the hash <key>, the whole hash expires after <duration> like Set.
+// It does not expire if <duration> <= 0.
+func (d *Dist) HSet(ctx context.Context, key string, fields map[string]interface{}, duration time.Duration) error {
+	values := make(map[string][]byte, len(fields))
+	for field, value := range fields {
+		v, err := d.convertOptionToArgs(value)
+		if err != nil {
+			return err
+		}
+		// 与redis一致，字段值按字符串保存，数字可以被HIncrBy递增
+		values[field] = []byte(gconv.String(v))
+	}
+	ttl := d.getInternalExpire(max(duration, 0))
+	return d.update(func(txn *badger.Txn) error {
+		// 重置其它字段的过期时间，使整个哈希同时过期
+		var entries []*badger.Entry
+		err := d.scanFields(txn, key, true, func(field string, item *badger.Item) error {
+			if _, ok := values[field]; ok {
+				return nil
+			}
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, badger.NewEntry(item.KeyCopy(nil), v).WithTTL(ttl))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for field, v := range values {
+			entries = append(entries, badger.NewEntry([]byte(key+HashFieldSep+field), v).WithTTL(ttl))
+		}
+		for _, e := range entries {
+			if err = txn.SetEntry(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// HGet returns the value of <field> in the hash <key>, it returns nil if it does not exist.
+func (d *Dist) HGet(ctx context.Context, key string, field string) (*gvar.Var, error) {
+	return d.Get(ctx, key+HashFieldSep+field)
+}
+
+// HGetAll returns all fields of the hash <key>, it returns an empty map if it does not exist.
+func (d *Dist) HGetAll(ctx context.Context, key string) (map[string]*gvar.Var, error) {
+	fields := make(map[string]*gvar.Var)
+	err := d.db.View(func(txn *badger.Txn) error {
+		return d.scanFields(txn, key, true, func(field string, item *badger.Item) error {
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			fields[field] = gvar.New(v)
+			return nil
+		})
+	})
+	return fields, err
+}
+
+// HDel deletes <fields> of the hash <key> and returns the number of the deleted fields.
+func (d *Dist) HDel(ctx context.Context, key string, fields ...string) (deleted int, err error) {
+	err = d.update(func(txn *badger.Txn) error {
+		deleted = 0
+		for _, field := range fields {
+			fieldKey := []byte(key + HashFieldSep + field)
+			_, err := txn.Get(fieldKey)
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err = txn.Delete(fieldKey); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return
+}
+
+// HIncrBy increments the integer value of <field> in the hash <key> by <delta> and returns
+// the new value. The hash <key> is created if it does not exist, which expires after
+// <duration>, and the existing hash keeps its expiry.
+func (d *Dist) HIncrBy(ctx context.Context, key string, field string, delta int64, duration time.Duration) (value int64, err error) {
+	fieldKey := []byte(key + HashFieldSep + field)
+	err = d.update(func(txn *badger.Txn) error {
+		value = delta
+		var expiresAt uint64
+		item, err := txn.Get(fieldKey)
+		switch {
+		case err == nil:
+			expiresAt = item.ExpiresAt()
+			old, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			n, err := strconv.ParseInt(string(old), 10, 64)
+			if err != nil {
+				return fmt.Errorf(`value of field "%s" of key "%s" is not an integer: %w`, field, key, err)
+			}
+			value += n
+		case errors.Is(err, badger.ErrKeyNotFound):
+			// 新字段使用哈希已有的过期时间
+			err = d.scanFields(txn, key, false, func(_ string, item *badger.Item) error {
+				expiresAt = item.ExpiresAt()
+				return errStopScan
+			})
+			if err != nil && !errors.Is(err, errStopScan) {
+				return err
+			}
+		default:
+			return err
+		}
+		e := badger.NewEntry(fieldKey, []byte(strconv.FormatInt(value, 10)))
+		if expiresAt > 0 {
+			e.ExpiresAt = expiresAt
+		} else {
+			e = e.WithTTL(d.getInternalExpire(max(duration, 0)))
+		}
+		return txn.SetEntry(e)
+	})
+	return
+}
+
+// errStopScan 提前结束遍历
+var errStopScan = errors.New("stop scan")
+
+// deleteFields 删除哈希的全部字段，所有键共用一个迭代器定位字段前缀
+func (d *Dist) deleteFields(txn *badger.Txn, keys []interface{}) error {
+	var fieldKeys [][]byte
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	for _, key := range keys {
+		prefix := hashFieldPrefix(gconv.String(key))
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			fieldKeys = append(fieldKeys, it.Item().KeyCopy(nil))
+		}
+	}
+	it.Close()
+	for _, fieldKey := range fieldKeys {
+		if err := txn.Delete(fieldKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+* @desc:磁盘缓存的锁，在事务中检查持有者
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 01:20
+ */
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// TryLock acquires the lock <key> for <owner> if it is not held, which expires after <ttl>.
+// The fencing token is incremented in <fenceKey> in the same transaction and returned.
+// Note that the expiry of badger is in seconds, the lock may be held up to one second longer.
+func (d *Dist) TryLock(ctx context.Context, key, fenceKey, owner string, ttl time.Duration) (token int64, ok bool, err error) {
+	err = d.update(func(txn *badger.Txn) error {
+		token, ok = 0, false
+		_, err := txn.Get([]byte(key))
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+		item, err := txn.Get([]byte(fenceKey))
+		switch {
+		case err == nil:
+			old, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if token, err = strconv.ParseInt(string(old), 10, 64); err != nil {
+				return err
+			}
+		case !errors.Is(err, badger.ErrKeyNotFound):
+			return err
+		}
+		token++
+		if err = txn.Set([]byte(fenceKey), []byte(strconv.FormatInt(token, 10))); err != nil {
+			return err
+		}
+		if err = txn.SetEntry(badger.NewEntry([]byte(key), []byte(owner)).WithTTL(lockTTL(ttl))); err != nil {
+			return err
+		}
+		ok = true
+		return nil
+	})
+	return
+}
+
+// RenewLock resets the expiry of the lock <key> to <ttl> if it is still held by <owner>.
+func (d *Dist) RenewLock(ctx context.Context, key, owner string, ttl time.Duration) (ok bool, err error) {
+	err = d.update(func(txn *badger.Txn) error {
+		held, err := d.lockHeld(txn, key, owner)
+		if ok = held; err != nil || !held {
+			return err
+		}
+		return txn.SetEntry(badger.NewEntry([]byte(key), []byte(owner)).WithTTL(lockTTL(ttl)))
+	})
+	return
+}
+
+// Unlock releases the lock <key> if it is still held by <owner>.
+func (d *Dist) Unlock(ctx context.Context, key, owner string) (ok bool, err error) {
+	err = d.update(func(txn *badger.Txn) error {
+		held, err := d.lockHeld(txn, key, owner)
+		if ok = held; err != nil || !held {
+			return err
+		}
+		return txn.Delete([]byte(key))
+	})
+	return
+}
+
+// lockHeld 锁是否由owner持有
+func (d *Dist) lockHeld(txn *badger.Txn, key, owner string) (bool, error) {
+	item, err := txn.Get([]byte(key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	v, err := item.ValueCopy(nil)
+	if err != nil {
+		return false, err
+	}
+	return string(v) == owner, nil
+}
+
+// lockTTL badger的过期时间精确到秒并向下取整，增加一秒保证锁至少保持ttl
+func lockTTL(ttl time.Duration) time.Duration {
+	return ttl + time.Second
+}
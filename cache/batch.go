@@ -0,0 +1,349 @@
+/*
+* @desc:批量读写，redis使用MGET及lua脚本，磁盘缓存使用单个事务
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 22:30
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/tiger1103/gfast-cache/adapter"
+)
+
+// ManyFunc is the loader of GetOrSetManyFunc, it returns the values of <keys> in the data
+// source. The keys not in the result are not cached, and the keys whose value is NotFound
+// are cached as known absent.
+type ManyFunc func(ctx context.Context, keys []string) (map[string]interface{}, error)
+
+// KEYS[1...n] 缓存键, KEYS[n+1...2n] 缓存所属标签的集合(有标签时), KEYS[2n+1...] 标签索引
+// ARGV[1] 键数量n, ARGV[3i-1...3i+1] 第i个键的缓存值、过期毫秒数和缓存键名(不含前缀), ARGV[3n+2...] 标签
+var redisSetManyScript = newRedisScript(redisLuaTagIndex + `
+local n = tonumber(ARGV[1])
+local now = nowMs()
+for i = 1, n do
+	local ttl = tonumber(ARGV[3 * i])
+	if ttl > 0 then
+		redis.call('SET', KEYS[i], ARGV[3 * i - 1], 'PX', ttl)
+	else
+		redis.call('SET', KEYS[i], ARGV[3 * i - 1])
+	end
+end
+for j = 2 * n + 1, #KEYS do
+	toZSet(KEYS[j])
+	for i = 1, n do
+		local ttl = tonumber(ARGV[3 * i])
+		local expireAt = '+inf'
+		if ttl > 0 then
+			expireAt = now + ttl
+		end
+		redis.call('ZADD', KEYS[j], expireAt, ARGV[3 * i + 1])
+	end
+	refresh(KEYS[j], now)
+end
+if #KEYS > n then
+	for i = 1, n do
+		for j = 2 * n + 1, #KEYS do
+			redis.call('SADD', KEYS[n + i], ARGV[n + 1 + j])
+		end
+		local ttl = tonumber(ARGV[3 * i])
+		if ttl > 0 then
+			redis.call('PEXPIRE', KEYS[n + i], ttl)
+		else
+			redis.call('PERSIST', KEYS[n + i])
+		end
+	end
+end
+return n
+`)
+
+// GetManyE returns the values of <keys>, the keys which do not exist are not in the result.
+// The keys of redis are read by MGET and the keys of the dist cache in one transaction.
+func (c *GfCache) GetManyE(ctx context.Context, keys []string) (map[string]*gvar.Var, error) {
+	if c.l2 != nil {
+		return c.tieredGetMany(ctx, keys, 0, func(missing []string) (map[string]*gvar.Var, error) {
+			return c.l2.GetManyE(ctx, missing)
+		})
+	}
+	entries, err := c.getManyEntries(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*gvar.Var, len(entries))
+	for key, e := range entries {
+		if !e.Absent {
+			result[key] = gvar.New(e.Value)
+		}
+	}
+	return result, nil
+}
+
+// GetMany returns the values of <keys>, the keys which do not exist are not in the result.
+// The keys of redis are read by MGET and the keys of the dist cache in one transaction.
+func (c *GfCache) GetMany(ctx context.Context, keys []string) map[string]*gvar.Var {
+	v, err := c.GetManyE(ctx, keys)
+	c.logError(ctx, err)
+	return v
+}
+
+// SetManyE sets the key-value pairs of <data>, which expire after <duration>, it does not
+// expire if <duration> <= 0. The keys of redis are written by one lua script for each
+// batch, and the keys of the dist cache in one write batch.
+func (c *GfCache) SetManyE(ctx context.Context, data map[string]interface{}, duration time.Duration, tag ...string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := c.setMany(ctx, data, duration, tag); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	return c.publish(ctx, busOpKeys, keys, "")
+}
+
+// SetMany sets the key-value pairs of <data>, which expire after <duration>, it does not
+// expire if <duration> <= 0. The keys of redis are written by one lua script for each
+// batch, and the keys of the dist cache in one write batch.
+func (c *GfCache) SetMany(ctx context.Context, data map[string]interface{}, duration time.Duration, tag ...string) {
+	c.logError(ctx, c.SetManyE(ctx, data, duration, tag...))
+}
+
+// GetOrSetManyFuncE returns the values of <keys>, and loads the missing ones by one call of
+// <f>, whose results are cached with <duration>. If <f> fails, the values in the cache are
+// returned along with the error.
+func (c *GfCache) GetOrSetManyFuncE(ctx context.Context, keys []string, f ManyFunc, duration time.Duration, tag ...string) (map[string]*gvar.Var, error) {
+	if c.l2 != nil {
+		return c.tieredGetMany(ctx, keys, duration, func(missing []string) (map[string]*gvar.Var, error) {
+			return c.l2.GetOrSetManyFuncE(ctx, missing, f, duration, tag...)
+		})
+	}
+	entries, err := c.getManyEntries(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		result  = make(map[string]*gvar.Var, len(keys))
+		missing = make(map[string]struct{})
+		load    = make([]string, 0)
+	)
+	for _, key := range keys {
+		if e, ok := entries[key]; ok {
+			if !e.Absent {
+				result[key] = gvar.New(e.Value)
+			}
+			continue
+		}
+		if _, ok := missing[key]; ok || c.bloomRejects(ctx, key) {
+			continue
+		}
+		missing[key] = struct{}{}
+		load = append(load, key)
+	}
+	if len(load) == 0 {
+		return result, nil
+	}
+	loaded, err := f(ctx, load)
+	if err != nil {
+		return result, err
+	}
+	values := make(map[string]interface{}, len(loaded))
+	for key, value := range loaded {
+		// 只缓存请求的键
+		if _, ok := missing[key]; !ok || value == nil {
+			continue
+		}
+		if value == NotFound {
+			if err = c.setEntry(ctx, key, &entry{Absent: true}, c.negativeDuration(duration), tag); err != nil {
+				return result, err
+			}
+			continue
+		}
+		values[key] = value
+		result[key] = gvar.New(value)
+	}
+	return result, c.setMany(ctx, values, duration, tag)
+}
+
+// GetOrSetManyFunc returns the values of <keys>, and loads the missing ones by one call of
+// <f>, whose results are cached with <duration>.
+func (c *GfCache) GetOrSetManyFunc(ctx context.Context, keys []string, f ManyFunc, duration time.Duration, tag ...string) map[string]*gvar.Var {
+	v, err := c.GetOrSetManyFuncE(ctx, keys, f, duration, tag...)
+	c.logError(ctx, err)
+	return v
+}
+
+// getManyEntries returns the valid entries of <keys>, including the absent ones.
+func (c *GfCache) getManyEntries(ctx context.Context, keys []string) (map[string]*entry, error) {
+	prefix := c.CachePrefix
+	if c.generation {
+		gens, err := c.loadGens(ctx, c.genKey())
+		if err != nil {
+			return nil, err
+		}
+		prefix = c.genDataPrefix(gens[0])
+	}
+	fullKeys := make([]string, len(keys))
+	for i, key := range keys {
+		fullKeys[i] = prefix + key
+	}
+	values, err := c.getMany(ctx, fullKeys)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		entries = make(map[string]*entry, len(keys))
+		now     = time.Now().UnixMilli()
+	)
+	for i, key := range keys {
+		v := values[fullKeys[i]]
+		if v.IsNil() {
+			continue
+		}
+		var e *entry
+		if c.generation {
+			if e, err = c.decodeValidEntry(ctx, v); err != nil {
+				return nil, err
+			}
+		} else {
+			decoded, ok, err := decodeEntry(v)
+			if err != nil {
+				return nil, err
+			}
+			if e = decoded; !ok {
+				e = &entry{Value: v.Val()}
+			}
+		}
+		if e == nil || (e.Value == nil && !e.Absent) || e.isExpired(now) {
+			continue
+		}
+		entries[key] = e
+	}
+	return entries, nil
+}
+
+// setMany 批量写入缓存，不发布失效消息
+func (c *GfCache) setMany(ctx context.Context, data map[string]interface{}, duration time.Duration, tag []string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if c.l2 != nil {
+		if err := c.l2.setMany(ctx, data, duration, tag); err != nil {
+			for key := range data {
+				c.l1Remove(ctx, key)
+			}
+			return err
+		}
+		for key, value := range data {
+			c.l1Set(ctx, key, value, duration)
+		}
+		return nil
+	}
+	durations := make(map[string]time.Duration, len(data))
+	for key, value := range data {
+		// 删除缓存的写入逐个处理
+		if value == nil || duration < 0 || c.generation {
+			if err := c.set(ctx, key, value, c.jitter(duration), tag); err != nil {
+				return err
+			}
+			continue
+		}
+		durations[key] = c.jitter(duration)
+	}
+	if len(durations) == 0 {
+		return nil
+	}
+	if c.redis != nil {
+		return c.redisSetMany(ctx, data, durations, tag)
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	if err := c.cacheTagKeys(ctx, durations, tag...); err != nil {
+		return err
+	}
+	if c.dist != nil {
+		items := make([]adapter.BatchItem, 0, len(durations))
+		for key, d := range durations {
+			items = append(items, adapter.BatchItem{Key: c.CachePrefix + key, Value: data[key], Duration: d})
+		}
+		return backendError(c.dist.SetBatch(ctx, items))
+	}
+	for key, d := range durations {
+		if err := c.cache.Set(ctx, c.CachePrefix+key, data[key], d); err != nil {
+			return backendError(err)
+		}
+	}
+	return nil
+}
+
+// redisSetMany writes the keys of <durations> with their values in <data> and updates the
+// indexes of <tag>, by one lua script for each batch.
+func (c *GfCache) redisSetMany(ctx context.Context, data map[string]interface{}, durations map[string]time.Duration, tag []string) error {
+	tags := make([]string, 0, len(tag))
+	for _, t := range tag {
+		if t != "" {
+			tags = append(tags, t)
+		}
+	}
+	keys := make([]string, 0, len(durations))
+	for key := range durations {
+		keys = append(keys, key)
+	}
+	for start := 0; start < len(keys); start += redisScanCount {
+		var (
+			batch     = keys[start:min(start+redisScanCount, len(keys))]
+			redisKeys = make([]string, 0, len(batch)*2+len(tags))
+			args      = make([]interface{}, 0, len(batch)*3+len(tags)+1)
+		)
+		args = append(args, len(batch))
+		for _, key := range batch {
+			redisKeys = append(redisKeys, c.CachePrefix+key)
+			args = append(args, data[key], durations[key].Milliseconds(), key)
+		}
+		if len(tags) > 0 {
+			for _, key := range batch {
+				redisKeys = append(redisKeys, c.CachePrefix+c.setKeyTagKey(key))
+			}
+			for _, t := range tags {
+				redisKeys = append(redisKeys, c.CachePrefix+c.setTagKey(t))
+				args = append(args, t)
+			}
+		}
+		if _, err := redisSetManyScript.Run(ctx, c.redis, redisKeys, args...); err != nil {
+			return backendError(err)
+		}
+	}
+	return nil
+}
+
+// tieredGetMany implements GetManyE and GetOrSetManyFuncE for the tiered cache, the keys
+// missing in L1 are read by <f> and promoted into L1.
+func (c *GfCache) tieredGetMany(ctx context.Context, keys []string, duration time.Duration, f func(missing []string) (map[string]*gvar.Var, error)) (map[string]*gvar.Var, error) {
+	var (
+		result  = make(map[string]*gvar.Var, len(keys))
+		missing = make([]string, 0)
+	)
+	for _, key := range keys {
+		if v := c.l1Get(ctx, key); !v.IsNil() {
+			result[key] = v
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	values, err := f(missing)
+	for key, v := range values {
+		result[key] = v
+		if err == nil {
+			c.l1Set(ctx, key, v.Val(), duration)
+		}
+	}
+	return result, err
+}
@@ -0,0 +1,236 @@
+/*
+* @desc:布隆过滤器，防止不存在的键穿透缓存
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 21:50
+ */
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+const (
+	// 过滤器的键名前缀，位于缓存前缀之外，不会被Clear删除
+	bloomKeyPrefix         = "__gfcache_bloom:"
+	defaultBloomExpected   = 1000000
+	defaultBloomFalsePRate = 0.01
+)
+
+// BloomOptions is the options of the bloom filter, see SetBloom.
+type BloomOptions struct {
+	Expected          uint    // 预计的成员数量，默认100万
+	FalsePositiveRate float64 // 误判率，默认0.01
+}
+
+// bloomFilter 布隆过滤器，redis缓存使用redis的位图，其它缓存使用内存中的位图
+type bloomFilter struct {
+	m    uint64       // 位数
+	k    uint64       // 哈希函数的数量
+	key  string       // 持久化的键名
+	mu   sync.RWMutex // 保护bits
+	bits []byte       // 与redis位图相同的布局，偏移0为第一个字节的最高位
+}
+
+var redisBloomAddScript = newRedisScript(`
+for i = 1, #ARGV do
+	redis.call('SETBIT', KEYS[1], ARGV[i], 1)
+end
+return 1
+`)
+
+var redisBloomTestScript = newRedisScript(`
+for i = 1, #ARGV do
+	if redis.call('GETBIT', KEYS[1], ARGV[i]) == 0 then
+		return 0
+	end
+end
+return 1
+`)
+
+// SetBloom enables the bloom filter guard of the cache, it should be called before the
+// cache is used. The members of the filter are the cache keys, GetOrSetFunc and the other
+// loading methods do not call the loader for the key which is definitely not in the
+// filter, and return ErrAbsent instead.
+//
+// The filter is persisted as a bitmap in redis for the redis cache, and as a key of badger
+// for the dist cache, which is loaded here and written by RebuildBloom and SaveBloom.
+// The filter of the memory cache is not persisted. The size and the number of hash
+// functions derived from <opts> are in the name of the persisted filter, so that the
+// filter of the different options is ignored and starts empty, call RebuildBloom to
+// rebuild it with all the existing keys.
+func (c *GfCache) SetBloom(ctx context.Context, opts BloomOptions) error {
+	if c.l2 != nil {
+		return c.l2.SetBloom(ctx, opts)
+	}
+	if opts.Expected == 0 {
+		opts.Expected = defaultBloomExpected
+	}
+	if opts.FalsePositiveRate <= 0 || opts.FalsePositiveRate >= 1 {
+		opts.FalsePositiveRate = defaultBloomFalsePRate
+	}
+	var (
+		n = float64(opts.Expected)
+		m = uint64(math.Ceil(-n * math.Log(opts.FalsePositiveRate) / (math.Ln2 * math.Ln2)))
+		b = &bloomFilter{
+			m: m,
+			k: max(uint64(math.Round(float64(m)/n*math.Ln2)), 1),
+		}
+	)
+	// 不同参数的位图互不兼容，分别保存
+	b.key = fmt.Sprintf("%s%d:%d:%s", bloomKeyPrefix, b.m, b.k, c.CachePrefix)
+	if c.redis == nil {
+		b.bits = make([]byte, (m+7)/8)
+	}
+	if c.dist != nil {
+		v, err := c.dist.Get(ctx, b.key)
+		if err != nil {
+			return backendError(err)
+		}
+		if bits := v.Bytes(); len(bits) == len(b.bits) {
+			b.bits = bits
+		}
+	}
+	c.bloom = b
+	return nil
+}
+
+// offsets returns the bit offsets of <member>.
+func (b *bloomFilter) offsets(member string) []uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(member))
+	sum := h.Sum64()
+	// 由一个64位哈希派生k个哈希(Kirsch-Mitzenmacher)
+	h1, h2 := sum&math.MaxUint32, sum>>32|1
+	offsets := make([]uint64, b.k)
+	for i := range offsets {
+		offsets[i] = (h1 + uint64(i)*h2) % b.m
+	}
+	return offsets
+}
+
+// setBits sets the bits of <members> in <bits>.
+func (b *bloomFilter) setBits(bits []byte, members []string) {
+	for _, member := range members {
+		for _, offset := range b.offsets(member) {
+			bits[offset/8] |= 0x80 >> (offset % 8)
+		}
+	}
+}
+
+// AddBloom adds <members>, which are the cache keys, into the bloom filter. The new keys
+// should be added before they are loaded, such as when the records are created.
+func (c *GfCache) AddBloom(ctx context.Context, members ...string) error {
+	if c.l2 != nil {
+		return c.l2.AddBloom(ctx, members...)
+	}
+	b := c.bloom
+	if b == nil || len(members) == 0 {
+		return nil
+	}
+	if c.redis != nil {
+		args := make([]interface{}, 0, len(members)*int(b.k))
+		for _, member := range members {
+			for _, offset := range b.offsets(member) {
+				args = append(args, offset)
+			}
+		}
+		_, err := redisBloomAddScript.Run(ctx, c.redis, []string{b.key}, args...)
+		return backendError(err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setBits(b.bits, members)
+	return nil
+}
+
+// RebuildBloom replaces the bloom filter with the one of <members>, and persists it.
+func (c *GfCache) RebuildBloom(ctx context.Context, members []string) error {
+	if c.l2 != nil {
+		return c.l2.RebuildBloom(ctx, members)
+	}
+	b := c.bloom
+	if b == nil {
+		return gerror.New("bloom filter is not enabled")
+	}
+	bits := make([]byte, (b.m+7)/8)
+	b.setBits(bits, members)
+	if c.redis != nil {
+		// 整体替换，重建期间不影响读取
+		_, err := c.redis.Set(ctx, b.key, string(bits))
+		return backendError(err)
+	}
+	b.mu.Lock()
+	b.bits = bits
+	b.mu.Unlock()
+	return c.SaveBloom(ctx)
+}
+
+// SaveBloom persists the bloom filter of the dist cache, the members added by AddBloom
+// are lost if the process exits before it is saved. It does nothing for the other caches,
+// as the filter of the redis cache is always persisted.
+func (c *GfCache) SaveBloom(ctx context.Context) error {
+	if c.l2 != nil {
+		return c.l2.SaveBloom(ctx)
+	}
+	b := c.bloom
+	if b == nil || c.dist == nil {
+		return nil
+	}
+	b.mu.RLock()
+	// 按字符串写入，[]byte会被序列化为json
+	bits := string(b.bits)
+	b.mu.RUnlock()
+	return backendError(c.dist.Set(ctx, b.key, bits, 0))
+}
+
+// BloomMayContain reports whether <member> may be in the bloom filter. It returns true
+// if the filter is not enabled.
+func (c *GfCache) BloomMayContain(ctx context.Context, member string) (bool, error) {
+	if c.l2 != nil {
+		return c.l2.BloomMayContain(ctx, member)
+	}
+	b := c.bloom
+	if b == nil {
+		return true, nil
+	}
+	offsets := b.offsets(member)
+	if c.redis != nil {
+		v, err := redisBloomTestScript.Run(ctx, c.redis, []string{b.key}, gconv.Interfaces(offsets)...)
+		if err != nil {
+			return false, backendError(err)
+		}
+		return v.Int() == 1, nil
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, offset := range offsets {
+		if b.bits[offset/8]&(0x80>>(offset%8)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// bloomRejects reports whether <key> is definitely not in the bloom filter, so that its
+// loader should not be called. The filter is bypassed if it is not available.
+func (c *GfCache) bloomRejects(ctx context.Context, key string) bool {
+	if c.bloom == nil {
+		return false
+	}
+	ok, err := c.BloomMayContain(ctx, key)
+	if err != nil {
+		g.Log().Error(ctx, err)
+		return false
+	}
+	return !ok
+}
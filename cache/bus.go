@@ -0,0 +1,158 @@
+/*
+* @desc:缓存失效消息总线，使多个节点的本地缓存保持一致
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 17:40
+ */
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// 默认的频道前缀，频道为前缀加缓存前缀
+const defaultBusChannelPrefix = "gfast-cache:bus:"
+
+// 失效消息的类型
+const (
+	busOpKeys       = "keys"       // 删除缓存键
+	busOpL1Keys     = "l1keys"     // 只删除二级缓存L1中的键，计数器和比较并交换的状态由各节点的本地缓存独立保存
+	busOpTag        = "tag"        // 删除标签
+	busOpPattern    = "pattern"    // 按模式删除标签
+	busOpClear      = "clear"      // 清空缓存前缀
+	busOpInvalidate = "invalidate" // 使缓存前缀失效
+)
+
+// Bus is the transport of the invalidation messages between the nodes.
+type Bus interface {
+	// Publish sends <message> to all the subscribers of <channel>.
+	Publish(ctx context.Context, channel string, message []byte) error
+	// Subscribe subscribes <channel> until <ctx> is done, it returns after the subscription
+	// is established. The <onMessage> is called for each message, and <onResync> is called
+	// after the subscription is re-established, as the messages may be lost in the meantime.
+	Subscribe(ctx context.Context, channel string, onMessage func(message []byte), onResync func()) error
+}
+
+// BusOptions 失效消息总线配置
+type BusOptions struct {
+	Bus     Bus    // 消息传输，如 NewRedisBus
+	NodeId  string // 节点ID，节点忽略自己发出的消息，默认随机生成
+	Channel string // 频道，默认为 "gfast-cache:bus:" 加缓存前缀
+}
+
+// busConfig 已启用的失效消息总线
+type busConfig struct {
+	bus     Bus
+	node    string
+	channel string
+}
+
+// invalidation 失效消息
+type invalidation struct {
+	Node string   `json:"node"`
+	Op   string   `json:"op"`
+	Keys []string `json:"keys,omitempty"`
+	Tag  string   `json:"tag,omitempty"`
+}
+
+// busMutedKey 应用其它节点的消息时不再发布消息
+type busMutedKey struct{}
+
+// SetBus enables the invalidation bus of the cache until <ctx> is done.
+//
+// Once enabled, Set, SetIfNotExist, Remove, Removes, RemoveByTag, RemoveByTagPattern,
+// Clear and Invalidate publish the invalidation of the key, tag or prefix, and the
+// other nodes apply it to their local caches: the memory or dist cache, and L1 of the
+// tiered cache. The redis cache is shared by the nodes and is not changed by the messages.
+// The GetOrSet family does not publish, as it only fills the missing keys. IncrBy and
+// CompareAndSet only invalidate L1 of the tiered caches, as the counters and the states of
+// the rate limiters in the memory or dist cache are kept by each node.
+//
+// The local cache is cleared after the bus reconnects, as the messages in the meantime
+// may be lost. If the change is applied but the invalidation cannot be published, the
+// error-returning methods return ErrPublish.
+func (c *GfCache) SetBus(ctx context.Context, opts BusOptions) error {
+	if opts.NodeId == "" {
+		opts.NodeId = guid.S()
+	}
+	if opts.Channel == "" {
+		opts.Channel = defaultBusChannelPrefix + c.CachePrefix
+	}
+	subCtx := context.WithoutCancel(ctx)
+	err := opts.Bus.Subscribe(ctx, opts.Channel, func(message []byte) {
+		var m invalidation
+		if err := json.Unmarshal(message, &m); err != nil {
+			g.Log().Error(subCtx, decodeError(err))
+			return
+		}
+		if m.Node == opts.NodeId {
+			return
+		}
+		c.logError(subCtx, c.applyInvalidation(subCtx, &m))
+	}, func() {
+		c.logError(subCtx, c.applyInvalidation(subCtx, &invalidation{Op: busOpClear}))
+	})
+	if err != nil {
+		return backendError(err)
+	}
+	c.bus.Store(&busConfig{
+		bus:     opts.Bus,
+		node:    opts.NodeId,
+		channel: opts.Channel,
+	})
+	return nil
+}
+
+// publish sends the invalidation to the other nodes if the bus is enabled.
+func (c *GfCache) publish(ctx context.Context, op string, keys []string, tag string) error {
+	bus := c.bus.Load()
+	if bus == nil || ctx.Value(busMutedKey{}) != nil {
+		return nil
+	}
+	message, err := json.Marshal(invalidation{
+		Node: bus.node,
+		Op:   op,
+		Keys: keys,
+		Tag:  tag,
+	})
+	if err != nil {
+		return decodeError(err)
+	}
+	// 写入已经成功，发布失败与后端故障区分
+	return publishError(bus.bus.Publish(ctx, bus.channel, message))
+}
+
+// applyInvalidation applies the invalidation from the other nodes to the local cache.
+func (c *GfCache) applyInvalidation(ctx context.Context, m *invalidation) error {
+	ctx = context.WithValue(ctx, busMutedKey{}, true)
+	if c.l2 != nil {
+		if m.Op == busOpKeys || m.Op == busOpL1Keys {
+			c.l1Remove(ctx, m.Keys...)
+		} else {
+			c.l1Clear(ctx)
+		}
+		return c.l2.applyInvalidation(ctx, m)
+	}
+	// redis为各节点共享的缓存
+	if c.redis != nil {
+		return nil
+	}
+	switch m.Op {
+	case busOpKeys:
+		return c.RemovesE(ctx, m.Keys)
+	case busOpTag:
+		return c.RemoveByTagE(ctx, m.Tag)
+	case busOpPattern:
+		return c.RemoveByTagPatternE(ctx, m.Tag)
+	case busOpClear:
+		return c.ClearE(ctx)
+	case busOpInvalidate:
+		return c.InvalidateE(ctx)
+	}
+	return nil
+}
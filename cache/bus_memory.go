@@ -0,0 +1,87 @@
+/*
+* @desc:进程内的失效消息总线，用于测试及单进程多实例
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 17:55
+ */
+
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBus is the in-process Bus, the messages are delivered synchronously.
+type MemoryBus struct {
+	mu   sync.RWMutex
+	subs map[string]map[*memorySubscriber]struct{}
+}
+
+// memorySubscriber 订阅者
+type memorySubscriber struct {
+	onMessage func(message []byte)
+	onResync  func()
+}
+
+// NewMemoryBus creates and returns an in-process Bus.
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		subs: make(map[string]map[*memorySubscriber]struct{}),
+	}
+}
+
+// Publish sends <message> to all the subscribers of <channel>.
+func (b *MemoryBus) Publish(ctx context.Context, channel string, message []byte) error {
+	for _, s := range b.subscribers(channel) {
+		s.onMessage(message)
+	}
+	return nil
+}
+
+// Subscribe subscribes <channel> until <ctx> is done.
+func (b *MemoryBus) Subscribe(ctx context.Context, channel string, onMessage func(message []byte), onResync func()) error {
+	s := &memorySubscriber{
+		onMessage: onMessage,
+		onResync:  onResync,
+	}
+	b.mu.Lock()
+	if b.subs[channel] == nil {
+		b.subs[channel] = make(map[*memorySubscriber]struct{})
+	}
+	b.subs[channel][s] = struct{}{}
+	b.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs[channel], s)
+		b.mu.Unlock()
+	}()
+	return nil
+}
+
+// Resync calls the resync callbacks of all the subscribers, as if the bus reconnected.
+func (b *MemoryBus) Resync() {
+	b.mu.RLock()
+	var subs []*memorySubscriber
+	for _, channelSubs := range b.subs {
+		for s := range channelSubs {
+			subs = append(subs, s)
+		}
+	}
+	b.mu.RUnlock()
+	for _, s := range subs {
+		s.onResync()
+	}
+}
+
+// subscribers returns the subscribers of <channel>.
+func (b *MemoryBus) subscribers(channel string) []*memorySubscriber {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	subs := make([]*memorySubscriber, 0, len(b.subs[channel]))
+	for s := range b.subs[channel] {
+		subs = append(subs, s)
+	}
+	return subs
+}
@@ -0,0 +1,83 @@
+/*
+* @desc:基于redis发布订阅的失效消息总线
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 17:50
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gredis"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 重新订阅的间隔
+const redisBusRetryInterval = time.Second
+
+// RedisBus is the Bus over redis pub/sub.
+type RedisBus struct {
+	redis *gredis.Redis
+}
+
+// NewRedisBus creates and returns a Bus over the pub/sub of redis <redisName>.
+func NewRedisBus(redisName ...string) *RedisBus {
+	return &RedisBus{
+		redis: g.Redis(redisName...),
+	}
+}
+
+// Publish sends <message> to all the subscribers of <channel>.
+func (b *RedisBus) Publish(ctx context.Context, channel string, message []byte) error {
+	_, err := b.redis.Publish(ctx, channel, string(message))
+	return err
+}
+
+// Subscribe subscribes <channel> until <ctx> is done, it returns after the subscription
+// is established. The subscription is re-established after the connection is broken,
+// and then <onResync> is called.
+func (b *RedisBus) Subscribe(ctx context.Context, channel string, onMessage func(message []byte), onResync func()) error {
+	conn, _, err := b.redis.Subscribe(ctx, channel)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			b.receive(ctx, conn, onMessage)
+			_ = conn.Close(ctx)
+			// 连接断开后重新订阅，期间的消息可能丢失
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(redisBusRetryInterval):
+				}
+				if conn, _, err = b.redis.Subscribe(ctx, channel); err == nil {
+					break
+				}
+				g.Log().Error(ctx, err)
+			}
+			onResync()
+		}
+	}()
+	return nil
+}
+
+// receive calls <onMessage> for each message until the connection is broken or <ctx> is done.
+func (b *RedisBus) receive(ctx context.Context, conn gredis.Conn, onMessage func(message []byte)) {
+	for {
+		v, err := conn.Receive(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				g.Log().Error(ctx, err)
+			}
+			return
+		}
+		if msg, ok := v.Val().(*gredis.Message); ok {
+			onMessage([]byte(msg.Payload))
+		}
+	}
+}
@@ -9,18 +9,24 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gogf/gf/v2/container/gvar"
-	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/database/gredis"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gcache"
-	"github.com/gogf/gf/v2/util/gconv"
 	"github.com/tiger1103/gfast-cache/adapter"
 	"github.com/tiger1103/gfast-cache/instance"
+	"github.com/tiger1103/gfast-cache/internal/singleflight"
+)
+
+const (
+	tagKeyPrefix    = "tag_"      // 标签索引的键前缀
+	keyTagKeyPrefix = "__keytag_" // 缓存所属标签索引的键前缀
 )
 
 type IGCache interface {
@@ -30,25 +36,64 @@ type IGCache interface {
 	Removes(ctx context.Context, keys []string)
 	RemoveByTag(ctx context.Context, tag string)
 	RemoveByTags(ctx context.Context, tag []string)
-	SetIfNotExist(ctx context.Context, key string, value interface{}, duration time.Duration, tag string) bool
-	GetOrSet(ctx context.Context, key string, value interface{}, duration time.Duration, tag string) *gvar.Var
-	GetOrSetFunc(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag string) *gvar.Var
-	GetOrSetFuncLock(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag string) *gvar.Var
+	RemoveByTagPattern(ctx context.Context, pattern string)
+	SetIfNotExist(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) bool
+	GetOrSet(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) *gvar.Var
+	GetOrSetFunc(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag ...string) *gvar.Var
+	GetOrSetFuncLock(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag ...string) *gvar.Var
+	GetOrSetFuncSWR(ctx context.Context, key string, f gcache.Func, freshTTL, staleTTL time.Duration, tag ...string) *gvar.Var
+	GetMany(ctx context.Context, keys []string) map[string]*gvar.Var
+	SetMany(ctx context.Context, data map[string]interface{}, duration time.Duration, tag ...string)
+	GetOrSetManyFunc(ctx context.Context, keys []string, f ManyFunc, duration time.Duration, tag ...string) map[string]*gvar.Var
+	Incr(ctx context.Context, key string, duration ...time.Duration) int64
+	IncrBy(ctx context.Context, key string, delta int64, duration ...time.Duration) int64
+	Decr(ctx context.Context, key string, duration ...time.Duration) int64
+	IncrByFloat(ctx context.Context, key string, delta float64, duration ...time.Duration) float64
+	HSet(ctx context.Context, key string, fields map[string]interface{}, duration time.Duration, tag ...string)
+	HGet(ctx context.Context, key string, field string) *gvar.Var
+	HGetAll(ctx context.Context, key string) map[string]*gvar.Var
+	HDel(ctx context.Context, key string, fields ...string) int
+	HIncrBy(ctx context.Context, key string, field string, delta int64, duration ...time.Duration) int64
+	GetWithVersion(ctx context.Context, key string) (*gvar.Var, uint64)
+	CompareAndSet(ctx context.Context, key string, expectedVersion uint64, value interface{}, ttl time.Duration) bool
 	Contains(ctx context.Context, key string) bool
 	Data(ctx context.Context) map[interface{}]interface{}
 	Keys(ctx context.Context) []interface{}
 	KeyStrings(ctx context.Context) []string
 	Values(ctx context.Context) []interface{}
 	Size(ctx context.Context) int
+	Clear(ctx context.Context)
+	Invalidate(ctx context.Context)
+	ListTags(ctx context.Context) []string
+	KeysByTag(ctx context.Context, tag string) []string
+	TagSize(ctx context.Context, tag string) int
+	TagsOfKey(ctx context.Context, key string) []string
 }
 
 type GfCache struct {
-	CachePrefix string //缓存前缀
-	cache       *gcache.Cache
-	tagSetMux   sync.Mutex
+	CachePrefix   string //缓存前缀，以分隔符结尾，且不能是其它前缀的开头
+	cache         *gcache.Cache
+	redis         *gredis.Redis //redis缓存时使用的客户端
+	dist          *adapter.Dist //磁盘缓存时使用的适配器
+	tagSetMux     sync.Mutex
+	generation    bool                      //是否使用版本号使缓存失效
+	genMux        sync.Mutex                //非redis缓存递增版本号时使用
+	l1            *gcache.Cache             //二级缓存的L1
+	l1TTL         time.Duration             //二级缓存L1的过期时间
+	l2            *GfCache                  //二级缓存的L2
+	bus           atomic.Pointer[busConfig] //失效消息总线，SetBus可以与缓存的读写并发调用
+	loadFlight    singleflight.Group        //合并同一个键的并发加载
+	refreshingSet sync.Map                  //正在后台刷新的键，每个键最多一个刷新协程
+	loadLock      *LoadLockOptions          //redis分布式加载锁配置
+	staleIfError  time.Duration             //加载失败时返回过期值的宽限期
+	earlyBeta     float64                   //提前过期(XFetch)的系数
+	ttlJitter     float64                   //过期时间的随机浮动比例
+	negativeTTL   time.Duration             //已知不存在的键的过期时间
+	bloom         *bloomFilter              //防止缓存穿透的布隆过滤器
 }
 
-// New 使用内存缓存
+// New 使用内存缓存。Keys、Data、Clear等按字符串前缀区分缓存，<cachePrefix> 应以 ":"、"_"
+// 等分隔符结尾，同一后端中的前缀不能互为开头，如 "ns_" 会包含 "ns_other_" 的键
 func New(cachePrefix string) *GfCache {
 	instanceKey := fmt.Sprintf("%s.%s", cachePrefix, "default")
 	cache := instance.GetOrSetFuncLock(instanceKey, func() interface{} {
@@ -65,9 +110,11 @@ func New(cachePrefix string) *GfCache {
 func NewRedis(cachePrefix string, redisName ...string) *GfCache {
 	instanceKey := fmt.Sprintf("%s.%s", cachePrefix, "adapterRedis")
 	cache := instance.GetOrSetFuncLock(instanceKey, func() interface{} {
+		redis := g.Redis(redisName...)
 		cache := &GfCache{
 			CachePrefix: cachePrefix,
-			cache:       gcache.NewWithAdapter(gcache.NewAdapterRedis(g.Redis(redisName...))),
+			cache:       gcache.NewWithAdapter(gcache.NewAdapterRedis(redis)),
+			redis:       redis,
 		}
 		return cache
 	})
@@ -76,84 +123,67 @@ func NewRedis(cachePrefix string, redisName ...string) *GfCache {
 
 func NewDist(cachePrefix ...string) *GfCache {
 	instanceKey := fmt.Sprintf("%s.%s", cachePrefix, "adapterDist")
+	// 磁盘适配器也使用instance管理，在锁外获取，避免两个键位于同一分组时死锁
+	dist := adapter.NewDist()
 	cache := instance.GetOrSetFuncLock(instanceKey, func() interface{} {
 		cache := &GfCache{
 			CachePrefix: cachePrefix[0],
-			cache:       gcache.NewWithAdapter(adapter.NewDist()),
+			cache:       gcache.NewWithAdapter(dist),
+			dist:        dist,
 		}
 		return cache
 	})
 	return cache.(*GfCache)
 }
 
-// 设置tag缓存的keys
-func (c *GfCache) cacheTagKey(ctx context.Context, key interface{}, tag string) {
-	tagKey := c.CachePrefix + c.setTagKey(tag)
-	if tagKey != "" {
-		tagValue := []interface{}{key}
-		value, _ := c.cache.Get(ctx, tagKey)
-		if !value.IsNil() {
-			var keyValue []interface{}
-			//若是字符串
-			if kStr, ok := value.Val().(string); ok {
-				js, err := gjson.DecodeToJson(kStr)
-				if err != nil {
-					g.Log().Error(ctx, err)
-					return
-				}
-				keyValue = gconv.SliceAny(js.Interface())
-			} else {
-				keyValue = gconv.SliceAny(value)
-			}
-			for _, v := range keyValue {
-				if !reflect.DeepEqual(key, v) {
-					tagValue = append(tagValue, v)
-				}
-			}
-		}
-		c.cache.Set(ctx, tagKey, tagValue, 0)
+// Redis returns the redis client of the cache, or the one of L2 for the tiered cache.
+// It returns nil if the cache is not backed by redis.
+func (c *GfCache) Redis() *gredis.Redis {
+	if c.l2 != nil {
+		return c.l2.Redis()
 	}
+	return c.redis
 }
 
 // 获取带标签的键名
 func (c *GfCache) setTagKey(tag string) string {
 	if tag != "" {
-		tag = "tag_" + tag
+		tag = tagKeyPrefix + tag
 	}
 	return tag
 }
 
+// 获取记录key所属标签的键名
+func (c *GfCache) setKeyTagKey(key string) string {
+	return keyTagKeyPrefix + key
+}
+
+// logError 记录错误，键不存在不视为错误
+func (c *GfCache) logError(ctx context.Context, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		g.Log().Error(ctx, err)
+	}
+}
+
 // Set sets cache with <tagKey>-<value> pair, which is expired after <duration>.
 // It does not expire if <duration> <= 0.
 func (c *GfCache) Set(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) {
-	c.tagSetMux.Lock()
-	if len(tag) > 0 {
-		c.cacheTagKey(ctx, key, tag[0])
-	}
-	err := c.cache.Set(ctx, c.CachePrefix+key, value, duration)
-	if err != nil {
-		g.Log().Error(ctx, err)
-	}
-	c.tagSetMux.Unlock()
+	c.logError(ctx, c.SetE(ctx, key, value, duration, tag...))
 }
 
 // SetIfNotExist sets cache with <tagKey>-<value> pair if <tagKey> does not exist in the cache,
 // which is expired after <duration>. It does not expire if <duration> <= 0.
-func (c *GfCache) SetIfNotExist(ctx context.Context, key string, value interface{}, duration time.Duration, tag string) bool {
-	c.tagSetMux.Lock()
-	defer c.tagSetMux.Unlock()
-	c.cacheTagKey(ctx, key, tag)
-	v, _ := c.cache.SetIfNotExist(ctx, c.CachePrefix+key, value, duration)
+func (c *GfCache) SetIfNotExist(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) bool {
+	v, err := c.SetIfNotExistE(ctx, key, value, duration, tag...)
+	c.logError(ctx, err)
 	return v
 }
 
 // Get returns the value of <tagKey>.
 // It returns nil if it does not exist or its value is nil.
 func (c *GfCache) Get(ctx context.Context, key string) *gvar.Var {
-	v, err := c.cache.Get(ctx, c.CachePrefix+key)
-	if err != nil {
-		g.Log().Error(ctx, err)
-	}
+	v, err := c.GetE(ctx, key)
+	c.logError(ctx, err)
 	return v
 }
 
@@ -162,22 +192,18 @@ func (c *GfCache) Get(ctx context.Context, key string) *gvar.Var {
 // The tagKey-value pair expires after <duration>.
 //
 // It does not expire if <duration> <= 0.
-func (c *GfCache) GetOrSet(ctx context.Context, key string, value interface{}, duration time.Duration, tag string) *gvar.Var {
-	c.tagSetMux.Lock()
-	defer c.tagSetMux.Unlock()
-	c.cacheTagKey(ctx, key, tag)
-	v, _ := c.cache.GetOrSet(ctx, c.CachePrefix+key, value, duration)
+func (c *GfCache) GetOrSet(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) *gvar.Var {
+	v, err := c.GetOrSetE(ctx, key, value, duration, tag...)
+	c.logError(ctx, err)
 	return v
 }
 
 // GetOrSetFunc returns the value of <tagKey>, or sets <tagKey> with result of function <f>
 // and returns its result if <tagKey> does not exist in the cache. The tagKey-value pair expires
 // after <duration>. It does not expire if <duration> <= 0.
-func (c *GfCache) GetOrSetFunc(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag string) *gvar.Var {
-	c.tagSetMux.Lock()
-	defer c.tagSetMux.Unlock()
-	c.cacheTagKey(ctx, key, tag)
-	v, _ := c.cache.GetOrSetFunc(ctx, c.CachePrefix+key, f, duration)
+func (c *GfCache) GetOrSetFunc(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag ...string) *gvar.Var {
+	v, err := c.GetOrSetFuncE(ctx, key, f, duration, tag...)
+	c.logError(ctx, err)
 	return v
 }
 
@@ -185,94 +211,75 @@ func (c *GfCache) GetOrSetFunc(ctx context.Context, key string, f gcache.Func, d
 // and returns its result if <tagKey> does not exist in the cache. The tagKey-value pair expires
 // after <duration>. It does not expire if <duration> <= 0.
 //
-// Note that the function <f> is executed within writing mutex lock.
-func (c *GfCache) GetOrSetFuncLock(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag string) *gvar.Var {
-	c.tagSetMux.Lock()
-	defer c.tagSetMux.Unlock()
-	c.cacheTagKey(ctx, key, tag)
-	v, _ := c.cache.GetOrSetFuncLock(ctx, c.CachePrefix+key, f, duration)
+// Note that the concurrent calls of the same key share one call of the function <f>,
+// and only one process in the cluster calls <f> for the redis cache.
+func (c *GfCache) GetOrSetFuncLock(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag ...string) *gvar.Var {
+	v, err := c.GetOrSetFuncLockE(ctx, key, f, duration, tag...)
+	c.logError(ctx, err)
 	return v
 }
 
 // Contains returns true if <tagKey> exists in the cache, or else returns false.
 func (c *GfCache) Contains(ctx context.Context, key string) bool {
-	v, _ := c.cache.Contains(ctx, c.CachePrefix+key)
+	v, err := c.ContainsE(ctx, key)
+	c.logError(ctx, err)
 	return v
 }
 
 // Remove deletes the <tagKey> in the cache, and returns its value.
 func (c *GfCache) Remove(ctx context.Context, key string) *gvar.Var {
-	v, _ := c.cache.Remove(ctx, c.CachePrefix+key)
+	v, err := c.RemoveE(ctx, key)
+	c.logError(ctx, err)
 	return v
 }
 
 // Removes deletes <keys> in the cache.
 func (c *GfCache) Removes(ctx context.Context, keys []string) {
-	keysWithPrefix := make([]interface{}, len(keys))
-	for k, v := range keys {
-		keysWithPrefix[k] = c.CachePrefix + v
-	}
-	c.cache.Remove(ctx, keysWithPrefix...)
+	c.logError(ctx, c.RemovesE(ctx, keys))
 }
 
 // RemoveByTag deletes the <tag> in the cache, and returns its value.
+// The <tag> is literal, use RemoveByTagPattern for the patterns like "org:1/*".
 func (c *GfCache) RemoveByTag(ctx context.Context, tag string) {
-	c.tagSetMux.Lock()
-	defer c.tagSetMux.Unlock()
-	tagKey := c.setTagKey(tag)
-	//删除tagKey 对应的 key和值
-	keys := c.Get(ctx, tagKey)
-	if !keys.IsNil() {
-		//如果是字符串
-		if kStr, ok := keys.Val().(string); ok {
-			js, err := gjson.DecodeToJson(kStr)
-			if err != nil {
-				g.Log().Error(ctx, err)
-				return
-			}
-			ks := gconv.SliceStr(js.Interface())
-			c.Removes(ctx, ks)
-		} else {
-			ks := gconv.SliceStr(keys.Val())
-			c.Removes(ctx, ks)
-		}
-	}
-	c.Remove(ctx, tagKey)
+	c.logError(ctx, c.RemoveByTagE(ctx, tag))
 }
 
 // RemoveByTags deletes <tags> in the cache.
 func (c *GfCache) RemoveByTags(ctx context.Context, tag []string) {
-	for _, v := range tag {
-		c.RemoveByTag(ctx, v)
-	}
+	c.logError(ctx, c.RemoveByTagsE(ctx, tag))
 }
 
-// Data returns a copy of all tagKey-value pairs in the cache as map type.
+// Data returns a copy of all key-value pairs of the cache prefix as map type.
 func (c *GfCache) Data(ctx context.Context) map[interface{}]interface{} {
-	v, _ := c.cache.Data(ctx)
+	v, err := c.DataE(ctx)
+	c.logError(ctx, err)
 	return v
 }
 
-// Keys returns all keys in the cache as slice.
+// Keys returns all keys of the cache prefix as slice.
 func (c *GfCache) Keys(ctx context.Context) []interface{} {
-	v, _ := c.cache.Keys(ctx)
+	v, err := c.KeysE(ctx)
+	c.logError(ctx, err)
 	return v
 }
 
-// KeyStrings returns all keys in the cache as string slice.
+// KeyStrings returns all keys of the cache prefix as string slice.
 func (c *GfCache) KeyStrings(ctx context.Context) []string {
-	v, _ := c.cache.KeyStrings(ctx)
+	v, err := c.KeyStringsE(ctx)
+	c.logError(ctx, err)
 	return v
 }
 
-// Values returns all values in the cache as slice.
+// Values returns all values of the cache prefix as slice.
 func (c *GfCache) Values(ctx context.Context) []interface{} {
-	v, _ := c.cache.Values(ctx)
+	v, err := c.ValuesE(ctx)
+	c.logError(ctx, err)
 	return v
 }
 
-// Size returns the size of the cache.
+// Size returns the number of keys of the cache prefix.
 func (c *GfCache) Size(ctx context.Context) int {
-	v, _ := c.cache.Size(ctx)
+	v, err := c.SizeE(ctx)
+	c.logError(ctx, err)
 	return v
 }
@@ -0,0 +1,478 @@
+/*
+* @desc:返回错误的缓存操作
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 10:30
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/os/gcache"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// IGCacheE is the error-returning variant of IGCache.
+// The backend errors are wrapped with ErrBackendUnavailable, so callers can tell
+// a backend outage from a cache miss, which is reported as ErrNotFound.
+type IGCacheE interface {
+	GetE(ctx context.Context, key string) (*gvar.Var, error)
+	SetE(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) error
+	RemoveE(ctx context.Context, key string) (*gvar.Var, error)
+	RemovesE(ctx context.Context, keys []string) error
+	RemoveByTagE(ctx context.Context, tag string) error
+	RemoveByTagsE(ctx context.Context, tag []string) error
+	RemoveByTagPatternE(ctx context.Context, pattern string) error
+	SetIfNotExistE(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) (bool, error)
+	GetOrSetE(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) (*gvar.Var, error)
+	GetOrSetFuncE(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag ...string) (*gvar.Var, error)
+	GetOrSetFuncLockE(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag ...string) (*gvar.Var, error)
+	GetOrSetFuncSWRE(ctx context.Context, key string, f gcache.Func, freshTTL, staleTTL time.Duration, tag ...string) (*gvar.Var, error)
+	GetManyE(ctx context.Context, keys []string) (map[string]*gvar.Var, error)
+	SetManyE(ctx context.Context, data map[string]interface{}, duration time.Duration, tag ...string) error
+	GetOrSetManyFuncE(ctx context.Context, keys []string, f ManyFunc, duration time.Duration, tag ...string) (map[string]*gvar.Var, error)
+	IncrE(ctx context.Context, key string, duration ...time.Duration) (int64, error)
+	IncrByE(ctx context.Context, key string, delta int64, duration ...time.Duration) (int64, error)
+	DecrE(ctx context.Context, key string, duration ...time.Duration) (int64, error)
+	IncrByFloatE(ctx context.Context, key string, delta float64, duration ...time.Duration) (float64, error)
+	HSetE(ctx context.Context, key string, fields map[string]interface{}, duration time.Duration, tag ...string) error
+	HGetE(ctx context.Context, key string, field string) (*gvar.Var, error)
+	HGetAllE(ctx context.Context, key string) (map[string]*gvar.Var, error)
+	HDelE(ctx context.Context, key string, fields ...string) (int, error)
+	HIncrByE(ctx context.Context, key string, field string, delta int64, duration ...time.Duration) (int64, error)
+	GetWithVersionE(ctx context.Context, key string) (*gvar.Var, uint64, error)
+	CompareAndSetE(ctx context.Context, key string, expectedVersion uint64, value interface{}, ttl time.Duration) (bool, error)
+	ContainsE(ctx context.Context, key string) (bool, error)
+	DataE(ctx context.Context) (map[interface{}]interface{}, error)
+	KeysE(ctx context.Context) ([]interface{}, error)
+	KeyStringsE(ctx context.Context) ([]string, error)
+	ValuesE(ctx context.Context) ([]interface{}, error)
+	SizeE(ctx context.Context) (int, error)
+	ClearE(ctx context.Context) error
+	InvalidateE(ctx context.Context) error
+	ListTagsE(ctx context.Context) ([]string, error)
+	KeysByTagE(ctx context.Context, tag string) ([]string, error)
+	TagSizeE(ctx context.Context, tag string) (int, error)
+	TagsOfKeyE(ctx context.Context, key string) ([]string, error)
+}
+
+// SetE sets cache with <key>-<value> pair, which is expired after <duration>.
+// It does not expire if <duration> <= 0.
+func (c *GfCache) SetE(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) error {
+	if err := c.set(ctx, key, value, c.jitter(duration), tag); err != nil {
+		return err
+	}
+	return c.publish(ctx, busOpKeys, []string{key}, "")
+}
+
+// set 写入缓存，不发布失效消息
+func (c *GfCache) set(ctx context.Context, key string, value interface{}, duration time.Duration, tag []string) error {
+	if c.l2 != nil {
+		return c.tieredSet(ctx, key, value, duration, tag)
+	}
+	if c.generation {
+		_, err := c.genSet(ctx, key, value, duration, false, tag)
+		return err
+	}
+	if c.redis != nil {
+		return c.redisSetE(ctx, key, value, duration, tag)
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	if err := c.cacheTagKey(ctx, key, duration, tag...); err != nil {
+		return err
+	}
+	return backendError(c.cache.Set(ctx, c.CachePrefix+key, value, duration))
+}
+
+// SetIfNotExistE sets cache with <key>-<value> pair if <key> does not exist in the cache,
+// which is expired after <duration>. It does not expire if <duration> <= 0.
+func (c *GfCache) SetIfNotExistE(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) (ok bool, err error) {
+	defer func() {
+		if err == nil && ok {
+			err = c.publish(ctx, busOpKeys, []string{key}, "")
+		}
+	}()
+	if c.l2 != nil {
+		defer c.l1Remove(ctx, key)
+		return c.l2.SetIfNotExistE(ctx, key, value, duration, tag...)
+	}
+	duration = c.jitter(duration)
+	if c.generation {
+		return c.genSet(ctx, key, value, duration, true, tag)
+	}
+	if c.redis != nil {
+		return c.redisSetIfNotExistE(ctx, key, value, duration, tag)
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	if err := c.removeDeadEntry(ctx, key); err != nil {
+		return false, err
+	}
+	if err := c.cacheTagKey(ctx, key, duration, tag...); err != nil {
+		return false, err
+	}
+	v, err := c.cache.SetIfNotExist(ctx, c.CachePrefix+key, value, duration)
+	return v, backendError(err)
+}
+
+// GetE returns the value of <key>.
+// It returns ErrNotFound if it does not exist or its value is nil, and ErrAbsent, which
+// wraps ErrNotFound, if it is cached as known absent, see NotFound.
+func (c *GfCache) GetE(ctx context.Context, key string) (*gvar.Var, error) {
+	if c.l2 != nil {
+		return c.tieredGet(ctx, key)
+	}
+	e, err := c.getEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return gvar.New(e.Value), nil
+}
+
+// GetOrSetE returns the value of <key>,
+// or sets <key>-<value> pair and returns <value> if <key> does not exist in the cache.
+// The key-value pair expires after <duration>. It does not expire if <duration> <= 0.
+func (c *GfCache) GetOrSetE(ctx context.Context, key string, value interface{}, duration time.Duration, tag ...string) (*gvar.Var, error) {
+	if c.l2 != nil {
+		return c.tieredGetOrSet(ctx, key, duration, func() (*gvar.Var, error) {
+			return c.l2.GetOrSetE(ctx, key, value, duration, tag...)
+		})
+	}
+	duration = c.jitter(duration)
+	if c.generation {
+		return c.genGetOrSet(ctx, key, value, duration, tag)
+	}
+	if c.redis != nil {
+		return c.redisGetOrSetE(ctx, key, value, duration, tag)
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	if err := c.removeDeadEntry(ctx, key); err != nil {
+		return nil, err
+	}
+	if err := c.cacheTagKey(ctx, key, duration, tag...); err != nil {
+		return nil, err
+	}
+	v, err := c.cache.GetOrSet(ctx, c.CachePrefix+key, value, duration)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	return unwrapVar(v)
+}
+
+// GetOrSetFuncE returns the value of <key>, or sets <key> with result of function <f>
+// and returns its result if <key> does not exist in the cache. The key-value pair expires
+// after <duration>. It does not expire if <duration> <= 0.
+//
+// The concurrent calls of the same <key> in the process share one call of <f> and its result.
+// The error of <f> is returned as it is, and nothing is cached in that case.
+func (c *GfCache) GetOrSetFuncE(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag ...string) (*gvar.Var, error) {
+	return c.getOrSetFunc(ctx, key, f, duration, false, tag...)
+}
+
+// GetOrSetFuncLockE works like GetOrSetFuncE. For the redis cache, it also holds a distributed
+// lock of <key> while calling <f>, so that only one process in the cluster calls <f> and
+// the others wait for its result, see SetLoadLock.
+func (c *GfCache) GetOrSetFuncLockE(ctx context.Context, key string, f gcache.Func, duration time.Duration, tag ...string) (*gvar.Var, error) {
+	return c.getOrSetFunc(ctx, key, f, duration, true, tag...)
+}
+
+// getOrSetFunc 读取缓存，缓存不存在时调用加载函数，同一个键的并发加载只执行一次，等待者共享结果，
+// 加载期间不持有全局锁
+func (c *GfCache) getOrSetFunc(ctx context.Context, key string, f gcache.Func, duration time.Duration, lock bool, tag ...string) (*gvar.Var, error) {
+	if c.l2 != nil {
+		return c.tieredGetOrSet(ctx, key, duration, func() (*gvar.Var, error) {
+			return c.l2.getOrSetFunc(ctx, key, f, duration, lock, tag...)
+		})
+	}
+	var (
+		now   = time.Now().UnixMilli()
+		early *entry // 提前重算的未过期条目
+	)
+	stale, err := c.lookupEntry(ctx, key)
+	switch {
+	case err == nil && stale.Absent && !stale.isExpired(now):
+		return nil, ErrAbsent
+	case err == nil && !stale.isExpired(now):
+		if !c.recomputeEarly(stale, now) {
+			return gvar.New(stale.Value), nil
+		}
+		early, stale = stale, nil
+	case err != nil && !errors.Is(err, ErrNotFound):
+		return nil, err
+	case stale == nil && c.bloomRejects(ctx, key):
+		return nil, ErrAbsent
+	}
+	load := func() (*gvar.Var, error) {
+		// 可能已被前一次加载写入，提前重算时只有条目已更新才直接返回
+		e, err := c.getEntry(ctx, key)
+		switch {
+		case errors.Is(err, ErrAbsent):
+			return nil, err
+		case errors.Is(err, ErrNotFound):
+		case err != nil:
+			return nil, err
+		case early == nil || e.ExpireAt != early.ExpireAt:
+			return gvar.New(e.Value), nil
+		}
+		return c.loadValue(ctx, key, f, duration, 0, tag)
+	}
+	result, err, _ := c.loadFlight.Do(key, func() (interface{}, error) {
+		if lock && c.redis != nil {
+			return c.redisLockLoad(ctx, key, load)
+		}
+		return load()
+	})
+	v, _ := result.(*gvar.Var)
+	if err != nil && early != nil && !errors.Is(err, ErrAbsent) {
+		// 提前重算失败时返回仍未过期的值
+		c.logError(ctx, err)
+		return gvar.New(early.Value), nil
+	}
+	// 加载失败时返回宽限期内的过期值
+	return c.staleFallback(stale, v, err)
+}
+
+// loadValue calls <f> and writes its result into <key>. If <fresh> > 0, the result is
+// written as an entry which is soft expired after <fresh>. If stale-if-error is enabled,
+// the entry is kept for the grace period after <duration>.
+//
+// In generation mode, the generations of the tags are read before calling <f>, so that
+// the result of <f> is invalidated if the tags are invalidated during the calling.
+func (c *GfCache) loadValue(ctx context.Context, key string, f gcache.Func, duration, fresh time.Duration, tag []string) (*gvar.Var, error) {
+	var (
+		physicalKey string
+		exists      bool
+		gens        map[string]int64
+		err         error
+	)
+	if c.generation {
+		if physicalKey, _, exists, err = c.genLoad(ctx, key); err != nil {
+			return nil, err
+		}
+		if gens, err = c.tagGens(ctx, tag, duration); err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+	value, err := f(ctx)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	var (
+		now = time.Now()
+		e   = &entry{Value: value, Cost: now.Sub(start).Milliseconds()}
+	)
+	if value == NotFound {
+		// 不存在的结果使用单独的过期时间，不参与软过期和宽限期
+		e = &entry{Absent: true}
+		duration = c.negativeDuration(duration)
+	} else {
+		if fresh > 0 {
+			e.FreshUntil = now.Add(fresh).UnixMilli()
+		}
+		duration = c.withExpiry(e, now, c.jitter(duration))
+	}
+	switch {
+	case c.generation:
+		_, err = c.genWrite(ctx, physicalKey, exists, e, duration, gens, false)
+	case e.Absent || e.FreshUntil > 0 || e.ExpireAt > 0:
+		err = c.setEntry(ctx, key, e, duration, tag)
+	default:
+		err = c.set(ctx, key, value, duration, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.Absent {
+		return nil, ErrAbsent
+	}
+	return gvar.New(value), nil
+}
+
+// ContainsE returns true if <key> exists in the cache, or else returns false.
+// It returns false and ErrAbsent if <key> is cached as known absent, see NotFound.
+func (c *GfCache) ContainsE(ctx context.Context, key string) (bool, error) {
+	if c.l2 != nil {
+		if !c.l1Get(ctx, key).IsNil() {
+			return true, nil
+		}
+		return c.l2.ContainsE(ctx, key)
+	}
+	// 需要读取条目以区分已知不存在的键和已过期的值
+	_, err := c.getEntry(ctx, key)
+	switch {
+	case errors.Is(err, ErrAbsent):
+		return false, err
+	case errors.Is(err, ErrNotFound):
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RemoveE deletes the <key> in the cache, and returns its value.
+// The <key> is also removed from the indexes of its tags.
+func (c *GfCache) RemoveE(ctx context.Context, key string) (v *gvar.Var, err error) {
+	defer func() {
+		if err == nil {
+			err = c.publish(ctx, busOpKeys, []string{key}, "")
+		}
+	}()
+	if c.l2 != nil {
+		c.l1Remove(ctx, key)
+		return c.l2.RemoveE(ctx, key)
+	}
+	if c.generation {
+		return c.genRemove(ctx, key)
+	}
+	if c.redis != nil {
+		return c.redisRemove(ctx, key)
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	if err = c.removeKeyTags(ctx, key, ""); err != nil {
+		return nil, err
+	}
+	if v, err = c.cache.Remove(ctx, c.CachePrefix+key); err != nil {
+		return nil, backendError(err)
+	}
+	return unwrapVar(v)
+}
+
+// RemovesE deletes <keys> in the cache.
+// The <keys> are also removed from the indexes of their tags.
+func (c *GfCache) RemovesE(ctx context.Context, keys []string) (err error) {
+	if len(keys) == 0 {
+		return nil
+	}
+	defer func() {
+		if err == nil {
+			err = c.publish(ctx, busOpKeys, keys, "")
+		}
+	}()
+	if c.l2 != nil {
+		c.l1Remove(ctx, keys...)
+		return c.l2.RemovesE(ctx, keys)
+	}
+	if c.generation {
+		_, err := c.genRemove(ctx, keys...)
+		return err
+	}
+	if c.redis != nil {
+		_, err := c.redisRemove(ctx, keys...)
+		return err
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	for _, key := range keys {
+		if err := c.removeKeyTags(ctx, key, ""); err != nil {
+			return err
+		}
+	}
+	return c.removeKeys(ctx, keys)
+}
+
+// removeKeys 直接删除缓存，不处理标签索引
+func (c *GfCache) removeKeys(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	keysWithPrefix := make([]interface{}, len(keys))
+	for k, v := range keys {
+		keysWithPrefix[k] = c.CachePrefix + v
+	}
+	_, err := c.cache.Remove(ctx, keysWithPrefix...)
+	return backendError(err)
+}
+
+// RemoveByTagE deletes the keys of <tag> and the <tag> itself in the cache.
+// The deleted keys are also removed from the indexes of their other tags.
+// The <tag> is literal even if it contains wildcards, use RemoveByTagPatternE for the patterns.
+func (c *GfCache) RemoveByTagE(ctx context.Context, tag string) error {
+	if err := c.removeTag(ctx, tag); err != nil {
+		return err
+	}
+	return c.publish(ctx, busOpTag, nil, tag)
+}
+
+// removeTag 删除标签及其下的缓存
+func (c *GfCache) removeTag(ctx context.Context, tag string) error {
+	if c.l2 != nil {
+		defer c.l1Clear(ctx)
+		return c.l2.removeTag(ctx, tag)
+	}
+	if c.generation {
+		return c.incrTagGen(ctx, tag)
+	}
+	if c.redis != nil {
+		return c.redisRemoveByTag(ctx, tag)
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	return c.removeByTag(ctx, tag)
+}
+
+// RemoveByTagsE deletes <tags> in the cache.
+func (c *GfCache) RemoveByTagsE(ctx context.Context, tag []string) error {
+	for _, v := range tag {
+		if err := c.RemoveByTagE(ctx, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DataE returns a copy of all key-value pairs of the cache prefix as map type.
+// The keys are without the cache prefix, and the internal tag indexes are excluded.
+func (c *GfCache) DataE(ctx context.Context) (map[interface{}]interface{}, error) {
+	data, err := c.namespaceData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[interface{}]interface{}, len(data))
+	for k, v := range data {
+		m[k] = v
+	}
+	return m, nil
+}
+
+// KeysE returns all keys of the cache prefix as slice.
+// The keys are without the cache prefix, and the internal tag indexes are excluded.
+func (c *GfCache) KeysE(ctx context.Context) ([]interface{}, error) {
+	keys, err := c.namespaceKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return gconv.Interfaces(keys), nil
+}
+
+// KeyStringsE returns all keys of the cache prefix as string slice.
+// The keys are without the cache prefix, and the internal tag indexes are excluded.
+func (c *GfCache) KeyStringsE(ctx context.Context) ([]string, error) {
+	return c.namespaceKeys(ctx)
+}
+
+// ValuesE returns all values of the cache prefix as slice.
+func (c *GfCache) ValuesE(ctx context.Context) ([]interface{}, error) {
+	data, err := c.namespaceData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0, len(data))
+	for _, v := range data {
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// SizeE returns the number of keys of the cache prefix.
+func (c *GfCache) SizeE(ctx context.Context) (int, error) {
+	keys, err := c.namespaceKeys(ctx)
+	return len(keys), err
+}
@@ -0,0 +1,226 @@
+/*
+* @desc:基于版本的比较并交换，实现缓存的乐观并发控制
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 02:50
+ */
+
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"math/rand"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// 版本的上限，小于2^53，lua中的数字可以精确表示
+const maxVersion = 1 << 52
+
+// 与contentVersion一致，计算redis中值的版本
+const redisLuaVersion = `
+local function version(v)
+	if string.sub(v, 1, 13) == '{"__gfcache":' then
+		local ok, e = pcall(cjson.decode, v)
+		if ok and type(e) == 'table' and e.ver then
+			return e.ver
+		end
+	end
+	return tonumber(string.sub(redis.sha1hex(v), 1, 13), 16) + 1
+end
+`
+
+// KEYS[1] 缓存键
+// ARGV[1] 期望的版本, ARGV[2] 缓存值, ARGV[3] 过期毫秒数
+var redisCompareAndSetScript = newRedisScript(redisLuaVersion + `
+local cur = redis.call('GET', KEYS[1])
+local ver = 0
+if cur then
+	ver = version(cur)
+end
+if ver ~= tonumber(ARGV[1]) then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// newVersion returns a random version for the entry written by CompareAndSet, so that the
+// version is not reused after the key is written by the other methods.
+func newVersion() uint64 {
+	return uint64(rand.Int63n(maxVersion-1)) + 1
+}
+
+// contentVersion returns the version of the value <raw> which is not written by
+// CompareAndSet, it is derived from the first 52 bits of the sha1 of <raw>.
+func contentVersion(raw string) uint64 {
+	sum := sha1.Sum([]byte(raw))
+	return binary.BigEndian.Uint64(sum[:8])>>12 + 1
+}
+
+// GetWithVersionE returns the value of <key> and its version for CompareAndSetE.
+// It returns ErrNotFound and the version 0 if <key> does not exist. The expired, known
+// absent or invalidated key returns ErrNotFound along with its version, so that it can be
+// overwritten by CompareAndSetE.
+//
+// The version of the dist cache is the one of badger, which is changed by every write. The
+// version of the redis and memory caches is a random number recorded by CompareAndSetE, or
+// derived from the content of the value written by the other methods.
+func (c *GfCache) GetWithVersionE(ctx context.Context, key string) (*gvar.Var, uint64, error) {
+	if c.l2 != nil {
+		return c.l2.GetWithVersionE(ctx, key)
+	}
+	fullKey, err := c.casKey(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	raw, version, err := c.rawWithVersion(ctx, fullKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version == 0 {
+		return nil, 0, ErrNotFound
+	}
+	var e *entry
+	if c.generation {
+		if e, err = c.decodeValidEntry(ctx, raw); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		decoded, ok, err := decodeEntry(raw)
+		if err != nil {
+			return nil, 0, err
+		}
+		if e = decoded; !ok {
+			e = &entry{Value: raw.Val()}
+		}
+	}
+	switch {
+	case e == nil || e.isExpired(time.Now().UnixMilli()):
+		return nil, version, ErrNotFound
+	case e.Absent:
+		return nil, version, ErrAbsent
+	case e.Value == nil:
+		return nil, version, ErrNotFound
+	}
+	return gvar.New(e.Value), version, nil
+}
+
+// GetWithVersion returns the value of <key> and its version for CompareAndSet, the version
+// is 0 if <key> does not exist. See GetWithVersionE.
+func (c *GfCache) GetWithVersion(ctx context.Context, key string) (*gvar.Var, uint64) {
+	v, version, err := c.GetWithVersionE(ctx, key)
+	c.logError(ctx, err)
+	return v, version
+}
+
+// CompareAndSetE sets <key> to <value> if its version is still <expectedVersion> returned by
+// GetWithVersionE, which expires after <ttl>. It does not expire if <ttl> <= 0. The
+// <expectedVersion> 0 means that <key> does not exist. It returns false if <key> is
+// written by others after its version was read.
+//
+// Note that the memory cache only compares with the writes of CompareAndSetE atomically.
+func (c *GfCache) CompareAndSetE(ctx context.Context, key string, expectedVersion uint64, value interface{}, ttl time.Duration) (ok bool, err error) {
+	defer func() {
+		if err == nil && ok {
+			err = c.publish(ctx, busOpL1Keys, []string{key}, "")
+		}
+	}()
+	if c.l2 != nil {
+		defer c.l1Remove(ctx, key)
+		return c.l2.CompareAndSetE(ctx, key, expectedVersion, value, ttl)
+	}
+	// 不使用TTL抖动，调用方依赖精确的过期时间，如限流器的窗口
+	ttl = max(ttl, 0)
+	fullKey, err := c.casKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	e := &entry{Value: value}
+	// 磁盘缓存使用badger的版本
+	if c.dist == nil {
+		e.Version = newVersion()
+	}
+	data, err := c.encodeEntry(e)
+	if err != nil {
+		return false, err
+	}
+	return c.compareAndWrite(ctx, fullKey, expectedVersion, data, ttl)
+}
+
+// compareAndWrite writes the encoded entry <data> into <fullKey> if its version is still
+// <expectedVersion>, which expires after <ttl>. It does not expire if <ttl> is 0.
+func (c *GfCache) compareAndWrite(ctx context.Context, fullKey string, expectedVersion uint64, data interface{}, ttl time.Duration) (bool, error) {
+	switch {
+	case c.redis != nil:
+		v, err := redisCompareAndSetScript.Run(ctx, c.redis, []string{fullKey}, expectedVersion, data, ttl.Milliseconds())
+		if err != nil {
+			return false, backendError(err)
+		}
+		return v.Int() == 1, nil
+	case c.dist != nil:
+		ok, err := c.dist.CompareAndSet(ctx, fullKey, expectedVersion, data, ttl)
+		return ok, backendError(err)
+	}
+	mu := keyLock(fullKey)
+	mu.Lock()
+	defer mu.Unlock()
+	_, version, err := c.rawWithVersion(ctx, fullKey)
+	if err != nil || version != expectedVersion {
+		return false, err
+	}
+	return true, backendError(c.cache.Set(ctx, fullKey, data, ttl))
+}
+
+// CompareAndSet sets <key> to <value> if its version is still <expectedVersion> returned by
+// GetWithVersion, which expires after <ttl>. See CompareAndSetE.
+func (c *GfCache) CompareAndSet(ctx context.Context, key string, expectedVersion uint64, value interface{}, ttl time.Duration) bool {
+	ok, err := c.CompareAndSetE(ctx, key, expectedVersion, value, ttl)
+	c.logError(ctx, err)
+	return ok
+}
+
+// casKey returns the key of <key> in the backend, which is the physical key of the current
+// generation in generation mode.
+func (c *GfCache) casKey(ctx context.Context, key string) (string, error) {
+	if !c.generation {
+		return c.CachePrefix + key, nil
+	}
+	gens, err := c.loadGens(ctx, c.genKey())
+	if err != nil {
+		return "", err
+	}
+	return c.genDataPrefix(gens[0]) + key, nil
+}
+
+// rawWithVersion returns the value of <fullKey> as it is in the backend and its version,
+// the version is 0 if it does not exist.
+func (c *GfCache) rawWithVersion(ctx context.Context, fullKey string) (*gvar.Var, uint64, error) {
+	if c.dist != nil {
+		v, version, err := c.dist.GetWithVersion(ctx, fullKey)
+		return v, version, backendError(err)
+	}
+	v, err := c.cache.Get(ctx, fullKey)
+	if err != nil {
+		return nil, 0, backendError(err)
+	}
+	if v.IsNil() {
+		return nil, 0, nil
+	}
+	e, ok, err := decodeEntry(v)
+	if err != nil {
+		return nil, 0, err
+	}
+	if ok && e.Version > 0 {
+		return v, e.Version, nil
+	}
+	return v, contentVersion(gconv.String(v.Val())), nil
+}
@@ -0,0 +1,198 @@
+/*
+* @desc:原子计数器，redis使用INCRBY，磁盘缓存使用带冲突重试的事务，内存缓存使用分段锁
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 23:10
+ */
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/encoding/ghash"
+)
+
+// 内存缓存计数器的分段锁数量
+const counterStripes = 64
+
+// counterLocks 内存缓存计数器和哈希的分段锁，按键名哈希分段
+var counterLocks [counterStripes]sync.Mutex
+
+// keyLock returns the lock of <fullKey> for the read-modify-write operations of the memory cache.
+func keyLock(fullKey string) *sync.Mutex {
+	return &counterLocks[ghash.DJB([]byte(fullKey))%counterStripes]
+}
+
+// KEYS[1] 计数器的键
+// ARGV[1] 增量, ARGV[2] 新建时的过期毫秒数, ARGV[3] 是否为浮点数
+var redisIncrScript = newRedisScript(`
+local created = redis.call('EXISTS', KEYS[1]) == 0
+local v
+if ARGV[3] == '1' then
+	v = redis.call('INCRBYFLOAT', KEYS[1], ARGV[1])
+else
+	v = redis.call('INCRBY', KEYS[1], ARGV[1])
+end
+if created and tonumber(ARGV[2]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return v
+`)
+
+// IncrByE increments the integer value of <key> by <delta> atomically and returns the new
+// value. The <key> is created with <delta> if it does not exist, which expires after the
+// optional <duration>, and the existing <key> keeps its expiry.
+func (c *GfCache) IncrByE(ctx context.Context, key string, delta int64, duration ...time.Duration) (int64, error) {
+	v, err := c.incr(ctx, key, delta, false, duration)
+	if err != nil {
+		return 0, err
+	}
+	return v.Int64(), nil
+}
+
+// IncrBy increments the integer value of <key> by <delta> atomically and returns the new
+// value. The <key> is created with <delta> if it does not exist, which expires after the
+// optional <duration>, and the existing <key> keeps its expiry.
+func (c *GfCache) IncrBy(ctx context.Context, key string, delta int64, duration ...time.Duration) int64 {
+	v, err := c.IncrByE(ctx, key, delta, duration...)
+	c.logError(ctx, err)
+	return v
+}
+
+// IncrE increments the integer value of <key> by 1, see IncrByE.
+func (c *GfCache) IncrE(ctx context.Context, key string, duration ...time.Duration) (int64, error) {
+	return c.IncrByE(ctx, key, 1, duration...)
+}
+
+// Incr increments the integer value of <key> by 1, see IncrBy.
+func (c *GfCache) Incr(ctx context.Context, key string, duration ...time.Duration) int64 {
+	return c.IncrBy(ctx, key, 1, duration...)
+}
+
+// DecrE decrements the integer value of <key> by 1, see IncrByE.
+func (c *GfCache) DecrE(ctx context.Context, key string, duration ...time.Duration) (int64, error) {
+	return c.IncrByE(ctx, key, -1, duration...)
+}
+
+// Decr decrements the integer value of <key> by 1, see IncrBy.
+func (c *GfCache) Decr(ctx context.Context, key string, duration ...time.Duration) int64 {
+	return c.IncrBy(ctx, key, -1, duration...)
+}
+
+// IncrByFloatE increments the float value of <key> by <delta> atomically and returns the
+// new value, see IncrByE.
+func (c *GfCache) IncrByFloatE(ctx context.Context, key string, delta float64, duration ...time.Duration) (float64, error) {
+	v, err := c.incr(ctx, key, delta, true, duration)
+	if err != nil {
+		return 0, err
+	}
+	return v.Float64(), nil
+}
+
+// IncrByFloat increments the float value of <key> by <delta> atomically and returns the
+// new value, see IncrBy.
+func (c *GfCache) IncrByFloat(ctx context.Context, key string, delta float64, duration ...time.Duration) float64 {
+	v, err := c.IncrByFloatE(ctx, key, delta, duration...)
+	c.logError(ctx, err)
+	return v
+}
+
+// incr 原子地增加计数器，<delta>为int64或float64
+func (c *GfCache) incr(ctx context.Context, key string, delta interface{}, float bool, duration []time.Duration) (v *gvar.Var, err error) {
+	defer func() {
+		if err == nil {
+			err = c.publish(ctx, busOpL1Keys, []string{key}, "")
+		}
+	}()
+	if c.l2 != nil {
+		defer c.l1Remove(ctx, key)
+		return c.l2.incr(ctx, key, delta, float, duration)
+	}
+	var ttl time.Duration
+	if len(duration) > 0 {
+		// 不使用TTL抖动，计数器常用于固定窗口的配额
+		ttl = duration[0]
+	}
+	fullKey := c.CachePrefix + key
+	if c.generation {
+		gens, err := c.loadGens(ctx, c.genKey())
+		if err != nil {
+			return nil, err
+		}
+		fullKey = c.genDataPrefix(gens[0]) + key
+	}
+	switch {
+	case c.redis != nil:
+		isFloat := "0"
+		if float {
+			isFloat = "1"
+		}
+		v, err = redisIncrScript.Run(ctx, c.redis, []string{fullKey}, delta, ttl.Milliseconds(), isFloat)
+		return v, backendError(err)
+
+	case c.dist != nil:
+		var value interface{}
+		if float {
+			value, err = c.dist.IncrByFloat(ctx, fullKey, delta.(float64), ttl)
+		} else {
+			value, err = c.dist.IncrBy(ctx, fullKey, delta.(int64), ttl)
+		}
+		if err != nil {
+			return nil, backendError(err)
+		}
+		return gvar.New(value), nil
+	}
+	mu := keyLock(fullKey)
+	mu.Lock()
+	defer mu.Unlock()
+	old, err := c.cache.Get(ctx, fullKey)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	var value interface{}
+	if float {
+		value, err = addFloat(old, delta.(float64))
+	} else {
+		value, err = addInt(old, delta.(int64))
+	}
+	if err != nil {
+		return nil, decodeError(fmt.Errorf(`key "%s": %w`, key, err))
+	}
+	if !old.IsNil() {
+		// 保留原有的过期时间
+		if _, exists, err := c.cache.Update(ctx, fullKey, value); err != nil || exists {
+			return gvar.New(value), backendError(err)
+		}
+	}
+	return gvar.New(value), backendError(c.cache.Set(ctx, fullKey, value, ttl))
+}
+
+// addInt returns the integer value of <old> plus <delta>.
+func addInt(old *gvar.Var, delta int64) (int64, error) {
+	if old.IsNil() {
+		return delta, nil
+	}
+	n, err := strconv.ParseInt(old.String(), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(`value "%s" is not an integer`, old.String())
+	}
+	return n + delta, nil
+}
+
+// addFloat returns the float value of <old> plus <delta>.
+func addFloat(old *gvar.Var, delta float64) (float64, error) {
+	if old.IsNil() {
+		return delta, nil
+	}
+	n, err := strconv.ParseFloat(old.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf(`value "%s" is not a float`, old.String())
+	}
+	return n + delta, nil
+}
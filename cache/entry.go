@@ -0,0 +1,230 @@
+/*
+* @desc:缓存条目，保存缓存值及其元数据
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 16:10
+ */
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+)
+
+// entryMarker 序列化后的缓存条目以此开头，用于和普通缓存值区分
+var entryMarker = []byte(`{"__gfcache":`)
+
+// entry is the cached value along with its metadata. It is kept as it is by the memory
+// adapter and serialized as json by the redis and dist adapters.
+type entry struct {
+	Value      interface{}      // 缓存值
+	TagGens    map[string]int64 // 写入时各标签的版本
+	FreshUntil int64            // 软过期时间(毫秒时间戳)，之后的值仍可返回但需要刷新，0表示不使用
+	ExpireAt   int64            // 逻辑过期时间(毫秒时间戳)，之后的值只在加载失败时返回，0表示不使用
+	Cost       int64            // 加载函数的耗时(毫秒)，用于提前过期
+	Absent     bool             // 已知不存在的键
+	Version    uint64           // CompareAndSet写入的版本，0表示由其它方法写入
+}
+
+// entryJSON 缓存条目序列化格式
+type entryJSON struct {
+	Marker     int              `json:"__gfcache"`
+	Value      json.RawMessage  `json:"v"`
+	TagGens    map[string]int64 `json:"tg,omitempty"`
+	FreshUntil int64            `json:"fu,omitempty"`
+	ExpireAt   int64            `json:"ea,omitempty"`
+	Cost       int64            `json:"c,omitempty"`
+	Absent     bool             `json:"a,omitempty"`
+	Version    uint64           `json:"ver,omitempty"`
+}
+
+// encodeEntry returns the value of <e> to be written into the backend.
+func (c *GfCache) encodeEntry(e *entry) (interface{}, error) {
+	if c.redis == nil && c.dist == nil {
+		return e, nil
+	}
+	value := e.Value
+	// 与普通缓存一致，[]byte按字符串保存
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	data, err := json.Marshal(entryJSON{
+		Marker:     1,
+		Value:      raw,
+		TagGens:    e.TagGens,
+		FreshUntil: e.FreshUntil,
+		ExpireAt:   e.ExpireAt,
+		Cost:       e.Cost,
+		Absent:     e.Absent,
+		Version:    e.Version,
+	})
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	return string(data), nil
+}
+
+// decodeEntry decodes the cache entry from <v>, it returns false if <v> is a plain value.
+// The value of the decoded entry is in the same form as a plain value of the adapter,
+// such as the redis adapter returns a json string for a struct.
+func decodeEntry(v *gvar.Var) (*entry, bool, error) {
+	var raw []byte
+	switch x := v.Val().(type) {
+	case *entry:
+		return x, true, nil
+	case string:
+		raw = []byte(x)
+	case []byte:
+		raw = x
+	default:
+		return nil, false, nil
+	}
+	if !bytes.HasPrefix(raw, entryMarker) {
+		return nil, false, nil
+	}
+	var j entryJSON
+	if err := json.Unmarshal(raw, &j); err != nil {
+		return nil, false, decodeError(err)
+	}
+	e := &entry{
+		TagGens:    j.TagGens,
+		FreshUntil: j.FreshUntil,
+		ExpireAt:   j.ExpireAt,
+		Cost:       j.Cost,
+		Absent:     j.Absent,
+		Version:    j.Version,
+	}
+	switch {
+	case len(j.Value) == 0 || string(j.Value) == "null":
+	case j.Value[0] == '"':
+		var s string
+		if err := json.Unmarshal(j.Value, &s); err != nil {
+			return nil, false, decodeError(err)
+		}
+		e.Value = s
+	default:
+		e.Value = string(j.Value)
+	}
+	return e, true, nil
+}
+
+// isStale reports whether <e> is beyond its soft expiry.
+func (e *entry) isStale(now int64) bool {
+	return e.FreshUntil > 0 && now >= e.FreshUntil
+}
+
+// isExpired reports whether <e> is beyond its logical expiry and kept only for the
+// stale-if-error fallback.
+func (e *entry) isExpired(now int64) bool {
+	return e.ExpireAt > 0 && now >= e.ExpireAt
+}
+
+// unwrapVar returns the value of the cache entry in <v>, or <v> itself if it is a plain value.
+func unwrapVar(v *gvar.Var) (*gvar.Var, error) {
+	if v.IsNil() {
+		return v, nil
+	}
+	e, ok, err := decodeEntry(v)
+	if err != nil || !ok {
+		return v, err
+	}
+	return gvar.New(e.Value), nil
+}
+
+// getEntry returns the entry of <key>, the plain value is returned as an entry without
+// metadata. It returns ErrNotFound if <key> does not exist, its value is nil or it is expired,
+// and ErrAbsent if it is cached as known absent.
+func (c *GfCache) getEntry(ctx context.Context, key string) (*entry, error) {
+	e, err := c.lookupEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if e.isExpired(time.Now().UnixMilli()) {
+		return nil, ErrNotFound
+	}
+	if e.Absent {
+		return nil, ErrAbsent
+	}
+	return e, nil
+}
+
+// lookupEntry works like getEntry, but it also returns the expired and the absent entries.
+func (c *GfCache) lookupEntry(ctx context.Context, key string) (*entry, error) {
+	var e *entry
+	if c.generation {
+		_, valid, _, err := c.genLoad(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		e = valid
+	} else {
+		v, err := c.cache.Get(ctx, c.CachePrefix+key)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		if v.IsNil() {
+			return nil, ErrNotFound
+		}
+		decoded, ok, err := decodeEntry(v)
+		if err != nil {
+			return nil, err
+		}
+		if e = decoded; !ok {
+			e = &entry{Value: v.Val()}
+		}
+	}
+	if e == nil || (e.Value == nil && !e.Absent) {
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+// removeDeadEntry deletes <key> if its entry is beyond its logical expiry and kept only
+// for the stale-if-error fallback, or it is cached as known absent, so that the
+// write-if-absent methods of the adapters treat it as missing like ContainsE does.
+// The caller must hold tagSetMux.
+func (c *GfCache) removeDeadEntry(ctx context.Context, key string) error {
+	e, err := c.lookupEntry(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !e.Absent && !e.isExpired(time.Now().UnixMilli()) {
+		return nil
+	}
+	_, err = c.cache.Remove(ctx, c.CachePrefix+key)
+	return backendError(err)
+}
+
+// setEntry writes the entry <e> of <key> without publishing the invalidation.
+func (c *GfCache) setEntry(ctx context.Context, key string, e *entry, duration time.Duration, tag []string) error {
+	if c.generation {
+		physicalKey, _, exists, err := c.genLoad(ctx, key)
+		if err != nil {
+			return err
+		}
+		gens, err := c.tagGens(ctx, tag, duration)
+		if err != nil {
+			return err
+		}
+		_, err = c.genWrite(ctx, physicalKey, exists, e, duration, gens, false)
+		return err
+	}
+	data, err := c.encodeEntry(e)
+	if err != nil {
+		return err
+	}
+	return c.set(ctx, key, data, duration, tag)
+}
@@ -0,0 +1,72 @@
+/*
+* @desc:缓存错误
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 10:20
+ */
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned when the key does not exist in the cache.
+	ErrNotFound = errors.New("cache: key not found")
+	// ErrBackendUnavailable wraps the errors returned by the cache backend, eg: redis is down.
+	ErrBackendUnavailable = errors.New("cache: backend unavailable")
+	// ErrDecode is returned when a cached value or tag index cannot be decoded.
+	ErrDecode = errors.New("cache: decode failed")
+	// ErrStale is returned along with the last known good value when the loader fails and
+	// the expired value is still in its grace period, see SetStaleIfError. It wraps the error
+	// of the loader.
+	ErrStale = errors.New("cache: stale value served")
+	// ErrAbsent is returned when the key is cached as known absent, see NotFound.
+	// It wraps ErrNotFound, so the known absent key is also a cache miss for the callers
+	// which do not care about the difference.
+	ErrAbsent = fmt.Errorf("%w: known absent", ErrNotFound)
+	// ErrLockHeld is returned by TryLock when the lock is held by another owner.
+	ErrLockHeld = errors.New("cache: lock held by another owner")
+	// ErrLockNotHeld is returned by Unlock when the lock is expired or held by another owner.
+	ErrLockNotHeld = errors.New("cache: lock not held")
+	// ErrPublish is returned when the value is written, but the invalidation cannot be
+	// published to the other nodes, see SetBus. The other nodes may read the outdated
+	// value from their local caches until it expires.
+	ErrPublish = errors.New("cache: publish invalidation failed")
+	// ErrEmptyPrefix is returned by ClearE when the cache prefix is empty, as it would
+	// delete the keys of all the other prefixes in the same backend.
+	ErrEmptyPrefix = errors.New("cache: empty cache prefix")
+	// ErrInvalidInterval is returned by StartCompactTags when the interval is not positive.
+	ErrInvalidInterval = errors.New("cache: interval must be positive")
+)
+
+// backendError wraps <err> of the cache backend with ErrBackendUnavailable.
+func backendError(err error) error {
+	if err == nil || errors.Is(err, ErrBackendUnavailable) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrBackendUnavailable, err)
+}
+
+// decodeError wraps <err> with ErrDecode.
+func decodeError(err error) error {
+	if err == nil || errors.Is(err, ErrDecode) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrDecode, err)
+}
+
+// publishError wraps the error <err> of the bus with ErrPublish.
+func publishError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrPublish, err)
+}
+
+// staleError wraps the loader error <err> with ErrStale.
+func staleError(err error) error {
+	return fmt.Errorf("%w: %w", ErrStale, err)
+}
@@ -0,0 +1,528 @@
+/*
+* @desc:基于版本号的缓存失效，使整个前缀或标签的失效只需递增一个计数器
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 16:20
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/os/gcache"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/tiger1103/gfast-cache/adapter"
+)
+
+const (
+	genKeyName      = "__gen"      // 缓存前缀的版本号
+	tagGenKeyPrefix = "__gen_tag_" // 标签版本号的键前缀
+
+	genTTLMargin   = time.Minute                // 标签版本号比记录它的条目多保留的时间
+	genNeverExpire = 100 * 365 * 24 * time.Hour // 剩余时间超过此值的版本号视为不过期
+)
+
+// SetGeneration enables or disables the generation mode of the cache, it should be called
+// before the cache is used.
+//
+// In generation mode, the physical key contains the generation of the cache prefix, like
+// "prefix" + "gen7:" + key, and each entry records the generations of its tags when written.
+// Invalidate increments the generation of the prefix and RemoveByTag increments the
+// generation of the tag, so that both of them are O(1) and the outdated entries are no
+// longer read and age out by their TTL. The tag indexes are not maintained in this mode,
+// and the generation of a tag expires a while after the entries recording it.
+func (c *GfCache) SetGeneration(enabled bool) *GfCache {
+	if c.l2 != nil {
+		c.l2.SetGeneration(enabled)
+		return c
+	}
+	c.generation = enabled
+	return c
+}
+
+// 缓存前缀版本号的键名
+func (c *GfCache) genKey() string {
+	return c.CachePrefix + genKeyName
+}
+
+// 标签版本号的键名
+func (c *GfCache) tagGenKey(tag string) string {
+	return c.CachePrefix + tagGenKeyPrefix + tag
+}
+
+// 带版本号的缓存键前缀
+func (c *GfCache) genDataPrefix(gen int64) string {
+	return fmt.Sprintf("%sgen%d:", c.CachePrefix, gen)
+}
+
+// loadGens returns the generations of <genKeys>, the missing ones are 0.
+func (c *GfCache) loadGens(ctx context.Context, genKeys ...string) ([]int64, error) {
+	values, err := c.getMany(ctx, genKeys)
+	if err != nil {
+		return nil, err
+	}
+	gens := make([]int64, len(genKeys))
+	for i, genKey := range genKeys {
+		gens[i] = gconv.Int64(values[genKey].String())
+	}
+	return gens, nil
+}
+
+// KEYS 标签版本号的键
+// ARGV[1] 需要保留的毫秒数，0表示不过期, ARGV[2] 延长后的过期毫秒数, ARGV[3] 是否新建不存在的版本号
+var redisTagGensScript = newRedisScript(`
+local need, ttl = tonumber(ARGV[1]), tonumber(ARGV[2])
+local gens = {}
+for i, k in ipairs(KEYS) do
+	if ARGV[3] == '1' and redis.call('SET', k, 1, 'NX') then
+		if ttl > 0 then
+			redis.call('PEXPIRE', k, ttl)
+		end
+	else
+		local left = redis.call('PTTL', k)
+		if left >= 0 then
+			if need == 0 then
+				redis.call('PERSIST', k)
+			elseif left < need then
+				redis.call('PEXPIRE', k, ttl)
+			end
+		end
+	end
+	gens[i] = redis.call('GET', k)
+end
+return gens
+`)
+
+// KEYS[1] 标签版本号的键
+var redisIncrTagGenScript = newRedisScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return redis.call('INCR', KEYS[1])
+end
+return 0
+`)
+
+// incrGen increments the generation of <genKey>.
+func (c *GfCache) incrGen(ctx context.Context, genKey string) error {
+	if c.redis != nil {
+		_, err := c.redis.Incr(ctx, genKey)
+		return backendError(err)
+	}
+	c.genMux.Lock()
+	defer c.genMux.Unlock()
+	gens, err := c.loadGens(ctx, genKey)
+	if err != nil {
+		return err
+	}
+	return backendError(c.cache.Set(ctx, genKey, strconv.FormatInt(gens[0]+1, 10), 0))
+}
+
+// incrTagGen increments the generation of <tag> and keeps its TTL. The missing generation
+// is not created, as there is no entry recording it.
+func (c *GfCache) incrTagGen(ctx context.Context, tag string) error {
+	genKey := c.tagGenKey(tag)
+	if c.redis != nil {
+		_, err := redisIncrTagGenScript.Run(ctx, c.redis, []string{genKey})
+		return backendError(err)
+	}
+	c.genMux.Lock()
+	defer c.genMux.Unlock()
+	gens, err := c.loadGens(ctx, genKey)
+	if err != nil || gens[0] == 0 {
+		return err
+	}
+	_, _, err = c.cache.Update(ctx, genKey, strconv.FormatInt(gens[0]+1, 10))
+	return backendError(err)
+}
+
+// tagGens returns the current generations of <tags>. The generation of a new tag starts
+// from 1, so that the tag can be found by RemoveByTagPattern. The generations are kept at
+// least <genTTLMargin> longer than the entries written with <duration>, which records them.
+func (c *GfCache) tagGens(ctx context.Context, tags []string, duration time.Duration) (map[string]int64, error) {
+	var genKeys, names []string
+	for _, tag := range tags {
+		if tag != "" {
+			genKeys = append(genKeys, c.tagGenKey(tag))
+			names = append(names, tag)
+		}
+	}
+	if len(genKeys) == 0 {
+		return nil, nil
+	}
+	gens, err := c.keepGenKeys(ctx, genKeys, duration, true)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]int64, len(names))
+	for i, tag := range names {
+		result[tag] = gens[i]
+	}
+	return result, nil
+}
+
+// keepGens extends the TTL of the existing generations of the tags <gens> to cover the
+// entries written with <duration>.
+func (c *GfCache) keepGens(ctx context.Context, gens map[string]int64, duration time.Duration) error {
+	if len(gens) == 0 {
+		return nil
+	}
+	genKeys := make([]string, 0, len(gens))
+	for tag := range gens {
+		genKeys = append(genKeys, c.tagGenKey(tag))
+	}
+	_, err := c.keepGenKeys(ctx, genKeys, duration, false)
+	return err
+}
+
+// keepGenKeys extends the TTL of the generations <genKeys> and returns them. The missing
+// generations are created with 1 if <create> is true.
+func (c *GfCache) keepGenKeys(ctx context.Context, genKeys []string, duration time.Duration, create bool) ([]int64, error) {
+	// 版本号比条目多保留genTTLMargin，剩余时间不足一半时延长，避免每次写入都更新过期时间
+	var need, ttl time.Duration
+	if duration > 0 {
+		need, ttl = duration+genTTLMargin/2, duration+genTTLMargin
+	}
+	gens := make([]int64, len(genKeys))
+	if c.redis != nil {
+		v, err := redisTagGensScript.Run(ctx, c.redis, genKeys, need.Milliseconds(), ttl.Milliseconds(), create)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		for i, gen := range v.Strings() {
+			gens[i] = gconv.Int64(gen)
+		}
+		return gens, nil
+	}
+	c.genMux.Lock()
+	defer c.genMux.Unlock()
+	for i, genKey := range genKeys {
+		loaded, err := c.loadGens(ctx, genKey)
+		if err != nil {
+			return nil, err
+		}
+		if gens[i] = loaded[0]; gens[i] == 0 {
+			if create {
+				if err = c.cache.Set(ctx, genKey, "1", ttl); err != nil {
+					return nil, backendError(err)
+				}
+				gens[i] = 1
+			}
+			continue
+		}
+		left, err := c.cache.GetExpire(ctx, genKey)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		// 内存和磁盘适配器对不过期的键返回极大的剩余时间
+		if left >= genNeverExpire || (need > 0 && left >= need) {
+			continue
+		}
+		if _, err = c.cache.UpdateExpire(ctx, genKey, ttl); err != nil {
+			return nil, backendError(err)
+		}
+	}
+	return gens, nil
+}
+
+// isValidEntry reports whether the tags of <e> are not invalidated.
+func (c *GfCache) isValidEntry(ctx context.Context, e *entry) (bool, error) {
+	if len(e.TagGens) == 0 {
+		return true, nil
+	}
+	var (
+		genKeys = make([]string, 0, len(e.TagGens))
+		written = make([]int64, 0, len(e.TagGens))
+	)
+	for tag, gen := range e.TagGens {
+		genKeys = append(genKeys, c.tagGenKey(tag))
+		written = append(written, gen)
+	}
+	gens, err := c.loadGens(ctx, genKeys...)
+	if err != nil {
+		return false, err
+	}
+	for i, gen := range gens {
+		if gen != written[i] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// genLoad returns the physical key of <key> and its valid entry. The returned <exists>
+// is true if there is an entry in the backend, even though it is invalidated by its tags.
+func (c *GfCache) genLoad(ctx context.Context, key string) (physicalKey string, e *entry, exists bool, err error) {
+	gens, err := c.loadGens(ctx, c.genKey())
+	if err != nil {
+		return
+	}
+	physicalKey = c.genDataPrefix(gens[0]) + key
+	v, err := c.cache.Get(ctx, physicalKey)
+	if err != nil {
+		err = backendError(err)
+		return
+	}
+	if v.IsNil() {
+		return
+	}
+	exists = true
+	e, err = c.decodeValidEntry(ctx, v)
+	return
+}
+
+// decodeValidEntry decodes the entry from <v>, it returns nil if the entry is invalidated.
+func (c *GfCache) decodeValidEntry(ctx context.Context, v *gvar.Var) (*entry, error) {
+	e, ok, err := decodeEntry(v)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &entry{Value: v.Val()}, nil
+	}
+	valid, err := c.isValidEntry(ctx, e)
+	if err != nil || !valid {
+		return nil, err
+	}
+	return e, nil
+}
+
+// genWrite writes the entry <e> with the generations of its tags into <physicalKey>.
+// If <nx> is true, it writes only if <key> has no valid entry.
+func (c *GfCache) genWrite(ctx context.Context, physicalKey string, exists bool, e *entry, duration time.Duration, gens map[string]int64, nx bool) (bool, error) {
+	if (e.Value == nil && !e.Absent) || duration < 0 {
+		if nx {
+			return false, nil
+		}
+		_, err := c.cache.Remove(ctx, physicalKey)
+		return false, backendError(err)
+	}
+	e.TagGens = gens
+	data, err := c.encodeEntry(e)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case nx && !exists:
+		ok, err := c.cache.SetIfNotExist(ctx, physicalKey, data, duration)
+		if err != nil || !ok {
+			return false, backendError(err)
+		}
+	case nx:
+		// 已失效的条目只在未被并发的写入替换时覆盖
+		ok, err := c.genOverwrite(ctx, physicalKey, data, duration)
+		if err != nil || !ok {
+			return false, err
+		}
+	default:
+		if err = c.cache.Set(ctx, physicalKey, data, duration); err != nil {
+			return false, backendError(err)
+		}
+	}
+	// 最终的过期时间可能因抖动和宽限期长于读取版本号时的时间
+	return true, c.keepGens(ctx, gens, duration)
+}
+
+// genOverwrite writes the encoded entry <data> into <physicalKey> if it has no valid entry,
+// the check and the write are atomic by comparing the version of the entry.
+func (c *GfCache) genOverwrite(ctx context.Context, physicalKey string, data interface{}, duration time.Duration) (bool, error) {
+	raw, version, err := c.rawWithVersion(ctx, physicalKey)
+	if err != nil {
+		return false, err
+	}
+	if version > 0 {
+		e, err := c.decodeValidEntry(ctx, raw)
+		if err != nil {
+			return false, err
+		}
+		if e != nil && !e.Absent && !e.isExpired(time.Now().UnixMilli()) {
+			return false, nil
+		}
+	}
+	return c.compareAndWrite(ctx, physicalKey, version, data, duration)
+}
+
+// genSet implements SetE and SetIfNotExistE in generation mode.
+func (c *GfCache) genSet(ctx context.Context, key string, value interface{}, duration time.Duration, nx bool, tag []string) (bool, error) {
+	physicalKey, e, exists, err := c.genLoad(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	// 只为加载失败保留的过期条目和已知不存在的条目视为不存在
+	if nx && e != nil && !e.Absent && !e.isExpired(time.Now().UnixMilli()) {
+		return false, nil
+	}
+	gens, err := c.tagGens(ctx, tag, duration)
+	if err != nil {
+		return false, err
+	}
+	if f, ok := value.(gcache.Func); ok {
+		if value, err = f(ctx); err != nil {
+			return false, err
+		}
+	}
+	return c.genWrite(ctx, physicalKey, exists, &entry{Value: value}, duration, gens, nx)
+}
+
+// genGetOrSet implements GetOrSetE in generation mode.
+func (c *GfCache) genGetOrSet(ctx context.Context, key string, value interface{}, duration time.Duration, tag []string) (*gvar.Var, error) {
+	ok, err := c.genSet(ctx, key, value, duration, true, tag)
+	if err != nil || ok {
+		return gvar.New(value), err
+	}
+	v, err := c.GetE(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return gvar.New(value), nil
+	}
+	return v, err
+}
+
+// genRemove implements RemoveE and RemovesE in generation mode, it returns the value of
+// the last key.
+func (c *GfCache) genRemove(ctx context.Context, keys ...string) (*gvar.Var, error) {
+	gens, err := c.loadGens(ctx, c.genKey())
+	if err != nil {
+		return nil, err
+	}
+	physicalKeys := make([]interface{}, len(keys))
+	for i, key := range keys {
+		physicalKeys[i] = c.genDataPrefix(gens[0]) + key
+	}
+	v, err := c.cache.Remove(ctx, physicalKeys...)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	if v.IsNil() {
+		return nil, nil
+	}
+	e, ok, err := decodeEntry(v)
+	if err != nil || !ok {
+		return v, err
+	}
+	return gvar.New(e.Value), nil
+}
+
+// genEntries returns the valid entries of the current generation, the keys are without
+// the cache prefix and the generation.
+func (c *GfCache) genEntries(ctx context.Context) (map[string]*entry, error) {
+	gens, err := c.loadGens(ctx, c.genKey())
+	if err != nil {
+		return nil, err
+	}
+	prefix := c.genDataPrefix(gens[0])
+	scanned, err := c.scanKeys(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	// 哈希不是缓存条目，磁盘缓存哈希的字段保存为子键
+	physicalKeys := make([]string, 0, len(scanned))
+	for _, physicalKey := range scanned {
+		if !strings.Contains(physicalKey, adapter.HashFieldSep) {
+			physicalKeys = append(physicalKeys, physicalKey)
+		}
+	}
+	values, err := c.getMany(ctx, physicalKeys)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		entries = make(map[string]*entry, len(values))
+		now     = time.Now().UnixMilli()
+	)
+	for physicalKey, v := range values {
+		key := strings.TrimPrefix(physicalKey, prefix)
+		if v.IsNil() || isInternalKey(key) {
+			continue
+		}
+		if _, ok := v.Val().(hashMap); ok {
+			continue
+		}
+		e, err := c.decodeValidEntry(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil && e.Value != nil && !e.isExpired(now) {
+			entries[key] = e
+		}
+	}
+	return entries, nil
+}
+
+// genKeysByTag returns the keys of the valid entries which belong to <tag>.
+func (c *GfCache) genKeysByTag(ctx context.Context, tag string) ([]string, error) {
+	entries, err := c.genEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0)
+	for key, e := range entries {
+		if _, ok := e.TagGens[tag]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// genTags returns the tags of the valid entries, as the generations of the tags are kept
+// after the tags are removed.
+func (c *GfCache) genTags(ctx context.Context) ([]string, error) {
+	entries, err := c.genEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	tags := make([]string, 0)
+	for _, e := range entries {
+		for tag := range e.TagGens {
+			if _, ok := seen[tag]; !ok {
+				seen[tag] = struct{}{}
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, nil
+}
+
+// genTagsOfKey returns the tags of <key> if it has a valid entry.
+func (c *GfCache) genTagsOfKey(ctx context.Context, key string) ([]string, error) {
+	_, e, _, err := c.genLoad(ctx, key)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(e.TagGens))
+	for tag := range e.TagGens {
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// InvalidateE invalidates all keys of the cache prefix. In generation mode, it increments
+// the generation of the prefix and the outdated entries age out by their TTL, or else it
+// is the same as ClearE.
+func (c *GfCache) InvalidateE(ctx context.Context) (err error) {
+	defer func() {
+		if err == nil {
+			err = c.publish(ctx, busOpInvalidate, nil, "")
+		}
+	}()
+	if c.l2 != nil {
+		defer c.l1Clear(ctx)
+		return c.l2.InvalidateE(ctx)
+	}
+	if c.generation {
+		return c.incrGen(ctx, c.genKey())
+	}
+	return c.ClearE(ctx)
+}
+
+// Invalidate invalidates all keys of the cache prefix. In generation mode, it increments
+// the generation of the prefix and the outdated entries age out by their TTL, or else it
+// is the same as Clear.
+func (c *GfCache) Invalidate(ctx context.Context) {
+	c.logError(ctx, c.InvalidateE(ctx))
+}
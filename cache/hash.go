@@ -0,0 +1,455 @@
+/*
+* @desc:哈希的字段操作，redis使用原生哈希，磁盘缓存每个字段保存为一个子键，内存缓存使用按键加锁的map
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 00:20
+ */
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+)
+
+// hashTagGensField 版本号模式下记录哈希写入时各标签版本的字段
+const hashTagGensField = "__gfcache_tg"
+
+// hashMap 内存缓存中的哈希，写入时复制，读取时不需要加锁
+type hashMap map[string]interface{}
+
+// KEYS[1] 哈希的键, KEYS[2] 缓存所属标签的集合, KEYS[3...] 标签索引
+// ARGV[1] 过期毫秒数, ARGV[2] 缓存键名(不含前缀), ARGV[3] 字段数量n,
+// ARGV[4...3+2n] 字段和值, ARGV[4+2n...] 标签
+var redisHSetScript = newRedisScript(redisLuaTagIndex + `
+local n = tonumber(ARGV[3])
+local args = {'HSET', KEYS[1]}
+for i = 4, 3 + 2 * n do
+	table.insert(args, ARGV[i])
+end
+redis.call(unpack(args))
+local ttl = tonumber(ARGV[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+else
+	redis.call('PERSIST', KEYS[1])
+end
+addTags(KEYS[2], ARGV[2], ttl, 3, 4 + 2 * n)
+return n
+`)
+
+// KEYS[1] 哈希的键
+// ARGV[1] 字段, ARGV[2] 增量, ARGV[3] 新建时的过期毫秒数
+var redisHIncrByScript = newRedisScript(`
+local created = redis.call('EXISTS', KEYS[1]) == 0
+local v = redis.call('HINCRBY', KEYS[1], ARGV[1], ARGV[2])
+if created and tonumber(ARGV[3]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return v
+`)
+
+// HSetE sets the <fields> of the hash <key> and keeps its other fields. Like SetE, the whole
+// hash expires after <duration>, it does not expire if <duration> <= 0, and it is registered
+// under <tag>, so that it is deleted by Remove and RemoveByTag.
+func (c *GfCache) HSetE(ctx context.Context, key string, fields map[string]interface{}, duration time.Duration, tag ...string) (err error) {
+	if len(fields) == 0 {
+		return nil
+	}
+	defer func() {
+		if err == nil {
+			err = c.publish(ctx, busOpKeys, []string{key}, "")
+		}
+	}()
+	if c.l2 != nil {
+		defer c.l1Remove(ctx, key)
+		return c.l2.HSetE(ctx, key, fields, duration, tag...)
+	}
+	duration = max(duration, 0)
+	fullKey, tagGens, err := c.hashKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if c.generation {
+		// 版本号模式下不维护标签索引，标签版本保存在哈希的字段中
+		gens, err := c.tagGens(ctx, tag, duration)
+		if err != nil {
+			return err
+		}
+		if len(gens) > 0 {
+			if tagGens == nil {
+				tagGens = make(map[string]int64, len(gens))
+			}
+			for t, gen := range gens {
+				tagGens[t] = gen
+			}
+			data, err := json.Marshal(tagGens)
+			if err != nil {
+				return decodeError(err)
+			}
+			withGens := make(map[string]interface{}, len(fields)+1)
+			for field, v := range fields {
+				withGens[field] = v
+			}
+			withGens[hashTagGensField] = string(data)
+			fields = withGens
+		}
+		if err = c.hset(ctx, key, fullKey, fields, duration, nil); err != nil {
+			return err
+		}
+		// 整个哈希的过期时间被更新，之前写入的标签版本也需要保留
+		return c.keepGens(ctx, tagGens, duration)
+	}
+	return c.hset(ctx, key, fullKey, fields, duration, tag)
+}
+
+// HSet sets the <fields> of the hash <key> and keeps its other fields. Like Set, the whole
+// hash expires after <duration>, it does not expire if <duration> <= 0, and it is registered
+// under <tag>, so that it is deleted by Remove and RemoveByTag.
+func (c *GfCache) HSet(ctx context.Context, key string, fields map[string]interface{}, duration time.Duration, tag ...string) {
+	c.logError(ctx, c.HSetE(ctx, key, fields, duration, tag...))
+}
+
+// HGetE returns the value of <field> in the hash <key>.
+// It returns ErrNotFound if the hash or the field does not exist.
+func (c *GfCache) HGetE(ctx context.Context, key string, field string) (*gvar.Var, error) {
+	if c.l2 != nil {
+		return c.l2.HGetE(ctx, key, field)
+	}
+	fullKey, _, err := c.hashKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	v, err := c.hget(ctx, fullKey, field)
+	if err != nil {
+		return nil, err
+	}
+	if v.IsNil() {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+// HGet returns the value of <field> in the hash <key>.
+// It returns nil if the hash or the field does not exist.
+func (c *GfCache) HGet(ctx context.Context, key string, field string) *gvar.Var {
+	v, err := c.HGetE(ctx, key, field)
+	c.logError(ctx, err)
+	return v
+}
+
+// HGetAllE returns all fields of the hash <key>, it returns an empty map if the hash does
+// not exist.
+func (c *GfCache) HGetAllE(ctx context.Context, key string) (map[string]*gvar.Var, error) {
+	if c.l2 != nil {
+		return c.l2.HGetAllE(ctx, key)
+	}
+	fullKey, _, err := c.hashKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := c.hgetAll(ctx, fullKey)
+	if err != nil {
+		return nil, err
+	}
+	delete(fields, hashTagGensField)
+	return fields, nil
+}
+
+// HGetAll returns all fields of the hash <key>, it returns an empty map if the hash does
+// not exist.
+func (c *GfCache) HGetAll(ctx context.Context, key string) map[string]*gvar.Var {
+	v, err := c.HGetAllE(ctx, key)
+	c.logError(ctx, err)
+	if v == nil {
+		v = make(map[string]*gvar.Var)
+	}
+	return v
+}
+
+// HDelE deletes <fields> of the hash <key> and returns the number of the deleted fields.
+// The hash is deleted when it has no fields.
+func (c *GfCache) HDelE(ctx context.Context, key string, fields ...string) (deleted int, err error) {
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	defer func() {
+		if err == nil && deleted > 0 {
+			err = c.publish(ctx, busOpKeys, []string{key}, "")
+		}
+	}()
+	if c.l2 != nil {
+		defer c.l1Remove(ctx, key)
+		return c.l2.HDelE(ctx, key, fields...)
+	}
+	fullKey, _, err := c.hashKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return c.hdel(ctx, fullKey, fields)
+}
+
+// HDel deletes <fields> of the hash <key> and returns the number of the deleted fields.
+// The hash is deleted when it has no fields.
+func (c *GfCache) HDel(ctx context.Context, key string, fields ...string) int {
+	v, err := c.HDelE(ctx, key, fields...)
+	c.logError(ctx, err)
+	return v
+}
+
+// HIncrByE increments the integer value of <field> in the hash <key> by <delta> atomically
+// and returns the new value. The hash <key> is created if it does not exist, which expires
+// after the optional <duration>, and the existing hash keeps its expiry.
+func (c *GfCache) HIncrByE(ctx context.Context, key string, field string, delta int64, duration ...time.Duration) (v int64, err error) {
+	defer func() {
+		if err == nil {
+			err = c.publish(ctx, busOpKeys, []string{key}, "")
+		}
+	}()
+	if c.l2 != nil {
+		defer c.l1Remove(ctx, key)
+		return c.l2.HIncrByE(ctx, key, field, delta, duration...)
+	}
+	var ttl time.Duration
+	if len(duration) > 0 {
+		ttl = max(duration[0], 0)
+	}
+	fullKey, _, err := c.hashKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return c.hincrBy(ctx, key, fullKey, field, delta, ttl)
+}
+
+// HIncrBy increments the integer value of <field> in the hash <key> by <delta> atomically
+// and returns the new value. The hash <key> is created if it does not exist, which expires
+// after the optional <duration>, and the existing hash keeps its expiry.
+func (c *GfCache) HIncrBy(ctx context.Context, key string, field string, delta int64, duration ...time.Duration) int64 {
+	v, err := c.HIncrByE(ctx, key, field, delta, duration...)
+	c.logError(ctx, err)
+	return v
+}
+
+// hashKey returns the key of the hash <key> in the backend. In generation mode, it is the
+// physical key of the current generation, the hash invalidated by its tags is deleted and
+// the tag generations of the valid hash are returned.
+func (c *GfCache) hashKey(ctx context.Context, key string) (string, map[string]int64, error) {
+	if !c.generation {
+		return c.CachePrefix + key, nil, nil
+	}
+	gens, err := c.loadGens(ctx, c.genKey())
+	if err != nil {
+		return "", nil, err
+	}
+	physicalKey := c.genDataPrefix(gens[0]) + key
+	v, err := c.hget(ctx, physicalKey, hashTagGensField)
+	if err != nil || v.IsNil() {
+		return physicalKey, nil, err
+	}
+	var tagGens map[string]int64
+	if err = json.Unmarshal(v.Bytes(), &tagGens); err != nil {
+		return "", nil, decodeError(err)
+	}
+	valid, err := c.isValidEntry(ctx, &entry{TagGens: tagGens})
+	if err != nil {
+		return "", nil, err
+	}
+	if !valid {
+		_, err = c.cache.Remove(ctx, physicalKey)
+		return physicalKey, nil, backendError(err)
+	}
+	return physicalKey, tagGens, nil
+}
+
+// memoryHash returns the hash of <fullKey> in the memory cache, it returns nil if it does
+// not exist.
+func (c *GfCache) memoryHash(ctx context.Context, fullKey string) (hashMap, error) {
+	v, err := c.cache.Get(ctx, fullKey)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	if v.IsNil() {
+		return nil, nil
+	}
+	m, ok := v.Val().(hashMap)
+	if !ok {
+		return nil, decodeError(fmt.Errorf(`key "%s" is not a hash`, fullKey))
+	}
+	return m, nil
+}
+
+// hset 写入哈希的字段并重置其过期时间，<tag>为空时不更新标签索引
+func (c *GfCache) hset(ctx context.Context, key, fullKey string, fields map[string]interface{}, duration time.Duration, tag []string) error {
+	if c.redis != nil {
+		var (
+			keys = []string{fullKey, c.CachePrefix + c.setKeyTagKey(key)}
+			args = make([]interface{}, 0, 3+2*len(fields)+len(tag))
+		)
+		args = append(args, duration.Milliseconds(), key, len(fields))
+		for field, v := range fields {
+			args = append(args, field, v)
+		}
+		for _, t := range tag {
+			if t == "" {
+				continue
+			}
+			keys = append(keys, c.CachePrefix+c.setTagKey(t))
+			args = append(args, t)
+		}
+		_, err := redisHSetScript.Run(ctx, c.redis, keys, args...)
+		return backendError(err)
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	if err := c.cacheTagKey(ctx, key, duration, tag...); err != nil {
+		return err
+	}
+	if c.dist != nil {
+		return backendError(c.dist.HSet(ctx, fullKey, fields, duration))
+	}
+	mu := keyLock(fullKey)
+	mu.Lock()
+	defer mu.Unlock()
+	old, err := c.memoryHash(ctx, fullKey)
+	if err != nil {
+		return err
+	}
+	m := make(hashMap, len(old)+len(fields))
+	for field, v := range old {
+		m[field] = v
+	}
+	for field, v := range fields {
+		m[field] = v
+	}
+	return backendError(c.cache.Set(ctx, fullKey, m, duration))
+}
+
+// hget 读取哈希的字段，不存在时返回nil
+func (c *GfCache) hget(ctx context.Context, fullKey, field string) (*gvar.Var, error) {
+	switch {
+	case c.redis != nil:
+		v, err := c.redis.HGet(ctx, fullKey, field)
+		return v, backendError(err)
+	case c.dist != nil:
+		v, err := c.dist.HGet(ctx, fullKey, field)
+		return v, backendError(err)
+	}
+	m, err := c.memoryHash(ctx, fullKey)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := m[field]; ok {
+		return gvar.New(v), nil
+	}
+	return nil, nil
+}
+
+// hgetAll 读取哈希的全部字段
+func (c *GfCache) hgetAll(ctx context.Context, fullKey string) (map[string]*gvar.Var, error) {
+	switch {
+	case c.redis != nil:
+		v, err := c.redis.HGetAll(ctx, fullKey)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		fields := v.MapStrVar()
+		if fields == nil {
+			fields = make(map[string]*gvar.Var)
+		}
+		return fields, nil
+	case c.dist != nil:
+		fields, err := c.dist.HGetAll(ctx, fullKey)
+		return fields, backendError(err)
+	}
+	m, err := c.memoryHash(ctx, fullKey)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]*gvar.Var, len(m))
+	for field, v := range m {
+		fields[field] = gvar.New(v)
+	}
+	return fields, nil
+}
+
+// hdel 删除哈希的字段，返回删除的数量
+func (c *GfCache) hdel(ctx context.Context, fullKey string, fields []string) (int, error) {
+	switch {
+	case c.redis != nil:
+		n, err := c.redis.HDel(ctx, fullKey, fields...)
+		return int(n), backendError(err)
+	case c.dist != nil:
+		n, err := c.dist.HDel(ctx, fullKey, fields...)
+		return n, backendError(err)
+	}
+	mu := keyLock(fullKey)
+	mu.Lock()
+	defer mu.Unlock()
+	old, err := c.memoryHash(ctx, fullKey)
+	if err != nil {
+		return 0, err
+	}
+	m := make(hashMap, len(old))
+	for field, v := range old {
+		m[field] = v
+	}
+	deleted := 0
+	for _, field := range fields {
+		if _, ok := m[field]; ok {
+			delete(m, field)
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+	// 与redis一致，没有字段的哈希被删除
+	if len(m) == 0 {
+		_, err = c.cache.Remove(ctx, fullKey)
+		return deleted, backendError(err)
+	}
+	_, _, err = c.cache.Update(ctx, fullKey, m)
+	return deleted, backendError(err)
+}
+
+// hincrBy 原子地增加哈希字段的值，哈希不存在时创建并在<duration>后过期
+func (c *GfCache) hincrBy(ctx context.Context, key, fullKey, field string, delta int64, duration time.Duration) (int64, error) {
+	switch {
+	case c.redis != nil:
+		v, err := redisHIncrByScript.Run(ctx, c.redis, []string{fullKey}, field, delta, duration.Milliseconds())
+		if err != nil {
+			return 0, backendError(err)
+		}
+		return v.Int64(), nil
+	case c.dist != nil:
+		v, err := c.dist.HIncrBy(ctx, fullKey, field, delta, duration)
+		return v, backendError(err)
+	}
+	mu := keyLock(fullKey)
+	mu.Lock()
+	defer mu.Unlock()
+	old, err := c.memoryHash(ctx, fullKey)
+	if err != nil {
+		return 0, err
+	}
+	value, err := addInt(gvar.New(old[field]), delta)
+	if err != nil {
+		return 0, decodeError(fmt.Errorf(`field "%s" of key "%s": %w`, field, key, err))
+	}
+	m := make(hashMap, len(old)+1)
+	for f, v := range old {
+		m[f] = v
+	}
+	m[field] = value
+	if old != nil {
+		// 保留原有的过期时间
+		if _, exists, err := c.cache.Update(ctx, fullKey, m); err != nil || exists {
+			return value, backendError(err)
+		}
+	}
+	return value, backendError(c.cache.Set(ctx, fullKey, m, duration))
+}
@@ -0,0 +1,131 @@
+/*
+* @desc:redis分布式加载锁，缓存失效时集群中只有一个进程执行加载函数
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 19:10
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// 加载锁的键前缀
+const loadLockKeyPrefix = "__lock_"
+
+// 加载锁的默认配置
+const (
+	defaultLoadLockTTL      = 10 * time.Second
+	defaultLoadLockWait     = 5 * time.Second
+	defaultLoadLockInterval = 50 * time.Millisecond
+)
+
+// LoadLockOptions 分布式加载锁配置
+type LoadLockOptions struct {
+	TTL      time.Duration // 锁的过期时间，应大于加载函数的执行时间，默认10秒
+	Wait     time.Duration // 未获得锁时等待其它进程写入缓存的最长时间，超时后直接加载，默认5秒
+	Interval time.Duration // 等待期间检查缓存的间隔，默认50毫秒
+}
+
+// KEYS[1] 锁
+// ARGV[1] 持有者的令牌
+var redisUnlockScript = newRedisScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// SetLoadLock sets the options of the distributed lock used by GetOrSetFuncLock of the
+// redis cache, the zero fields use the default values.
+func (c *GfCache) SetLoadLock(opts LoadLockOptions) *GfCache {
+	if c.l2 != nil {
+		c.l2.SetLoadLock(opts)
+		return c
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultLoadLockTTL
+	}
+	if opts.Wait <= 0 {
+		opts.Wait = defaultLoadLockWait
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultLoadLockInterval
+	}
+	c.loadLock = &opts
+	return c
+}
+
+// loadLockOptions returns the options of the distributed load lock.
+func (c *GfCache) loadLockOptions() LoadLockOptions {
+	if c.loadLock != nil {
+		return *c.loadLock
+	}
+	return LoadLockOptions{
+		TTL:      defaultLoadLockTTL,
+		Wait:     defaultLoadLockWait,
+		Interval: defaultLoadLockInterval,
+	}
+}
+
+// redisTryLock acquires the lock <lockKey> with <token> by SET NX PX.
+func (c *GfCache) redisTryLock(ctx context.Context, lockKey, token string, ttl time.Duration) (bool, error) {
+	v, err := c.redis.Do(ctx, "SET", lockKey, token, "PX", ttl.Milliseconds(), "NX")
+	if err != nil {
+		return false, backendError(err)
+	}
+	return !v.IsNil(), nil
+}
+
+// redisUnlock releases the lock <lockKey> if it is still held by <token>.
+func (c *GfCache) redisUnlock(ctx context.Context, lockKey, token string) (bool, error) {
+	v, err := redisUnlockScript.Run(ctx, c.redis, []string{lockKey}, token)
+	if err != nil {
+		return false, backendError(err)
+	}
+	return v.Int() == 1, nil
+}
+
+// redisLockLoad calls <load> holding the distributed lock of <key>. The processes not
+// holding the lock wait until the value appears, or they acquire the lock after it is
+// released without a value. If the value does not appear in time, <load> is called
+// without the lock.
+func (c *GfCache) redisLockLoad(ctx context.Context, key string, load func() (*gvar.Var, error)) (*gvar.Var, error) {
+	var (
+		opts     = c.loadLockOptions()
+		lockKey  = c.CachePrefix + loadLockKeyPrefix + key
+		token    = guid.S()
+		deadline = time.Now().Add(opts.Wait)
+	)
+	for {
+		ok, err := c.redisTryLock(ctx, lockKey, token, opts.TTL)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			defer func() {
+				_, err := c.redisUnlock(context.WithoutCancel(ctx), lockKey, token)
+				c.logError(ctx, err)
+			}()
+			return load()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(opts.Interval):
+		}
+		v, err := c.GetE(ctx, key)
+		if !errors.Is(err, ErrNotFound) {
+			return v, err
+		}
+		if time.Now().After(deadline) {
+			return load()
+		}
+	}
+}
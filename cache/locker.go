@@ -0,0 +1,281 @@
+/*
+* @desc:基于缓存后端的分布式锁，支持自动续期和防护令牌
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 01:40
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+const (
+	// 锁和防护令牌的键名前缀，位于缓存前缀之外，不会被Clear删除
+	lockerKeyPrefix = "__gfcache_lock:"
+	fenceKeyPrefix  = "__gfcache_fence:"
+	// 锁的默认过期时间
+	defaultLockTTL = 10 * time.Second
+	// Lock等待锁释放时的重试间隔
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// KEYS[1] 锁, KEYS[2] 防护令牌
+// ARGV[1] 持有者, ARGV[2] 过期毫秒数
+var redisLockScript = newRedisScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2], 'NX') then
+	return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+// KEYS[1] 锁
+// ARGV[1] 持有者, ARGV[2] 过期毫秒数
+var redisRenewLockScript = newRedisScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// memoryLock 内存缓存的锁
+type memoryLock struct {
+	owner    string
+	expireAt time.Time
+}
+
+// 清理内存缓存中已过期的锁的间隔
+const memoryLockSweepInterval = time.Minute
+
+// memoryLocks 内存缓存的锁和防护令牌，只在当前进程内有效
+var memoryLocks = struct {
+	sync.Mutex
+	held    map[string]memoryLock
+	fences  map[string]int64
+	sweptAt time.Time // 上次清理过期锁的时间
+}{
+	held:   make(map[string]memoryLock),
+	fences: make(map[string]int64),
+}
+
+// Locker is the distributed lock built on the backend of a GfCache. The locks of the
+// redis and dist caches are shared by the processes using the same backend, and the
+// locks of the memory cache are only valid in the current process.
+type Locker struct {
+	c *GfCache
+}
+
+// Lock is a lock held by Locker. Its lease is renewed in background until it is unlocked
+// or lost, such as the backend is unavailable longer than its TTL.
+type Lock struct {
+	locker *Locker
+	name   string
+	key    string
+	owner  string
+	token  int64
+	ttl    time.Duration
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Locker returns the distributed lock built on the backend of the cache.
+func (c *GfCache) Locker() *Locker {
+	if c.l2 != nil {
+		return c.l2.Locker()
+	}
+	return &Locker{c: c}
+}
+
+// TryLock acquires the lock <name> without waiting, it returns ErrLockHeld if the lock is
+// held by another owner. The lock expires after <ttl> unless it is renewed, the lease is
+// renewed every third of <ttl> while held. The default <ttl> is 10 seconds if <ttl> <= 0.
+func (l *Locker) TryLock(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		ttl = defaultLockTTL
+	}
+	var (
+		c     = l.c
+		key   = lockerKeyPrefix + c.CachePrefix + name
+		owner = guid.S()
+	)
+	token, ok, err := l.tryLock(ctx, key, fenceKeyPrefix+c.CachePrefix+name, owner, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockHeld
+	}
+	renewCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	lock := &Lock{
+		locker: l,
+		name:   name,
+		key:    key,
+		owner:  owner,
+		token:  token,
+		ttl:    ttl,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go lock.keepAlive(renewCtx)
+	return lock, nil
+}
+
+// Lock acquires the lock <name>, it waits until the lock is released by the other owner or
+// <ctx> is done. See TryLock.
+func (l *Locker) Lock(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	for {
+		lock, err := l.TryLock(ctx, name, ttl)
+		if !errors.Is(err, ErrLockHeld) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// Name returns the name of the lock.
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Token returns the fencing token of the lock, which increases every time the lock <name>
+// is acquired. The storage written while holding the lock can reject the writes with a
+// token less than the last seen one, so that the owner whose lease is lost, such as it is
+// paused longer than the TTL, cannot overwrite the writes of the next owner.
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+// Done returns a channel which is closed when the lock is unlocked or its lease is lost.
+func (l *Lock) Done() <-chan struct{} {
+	return l.done
+}
+
+// Unlock releases the lock and stops renewing its lease. It returns ErrLockNotHeld if the
+// lock is expired or held by another owner, and the lock of the other owner is not released.
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.cancel()
+	<-l.done
+	ok, err := l.locker.unlock(ctx, l.key, l.owner)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// keepAlive 定期续期直到解锁或锁丢失
+func (l *Lock) keepAlive(ctx context.Context) {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ok, err := l.locker.renew(ctx, l.key, l.owner, l.ttl)
+		if ctx.Err() != nil {
+			return
+		}
+		// 后端暂时不可用时继续重试，锁过期后续期失败
+		l.locker.c.logError(ctx, err)
+		if err == nil && !ok {
+			return
+		}
+	}
+}
+
+// tryLock 获取锁并递增防护令牌
+func (l *Locker) tryLock(ctx context.Context, key, fenceKey, owner string, ttl time.Duration) (int64, bool, error) {
+	c := l.c
+	switch {
+	case c.redis != nil:
+		v, err := redisLockScript.Run(ctx, c.redis, []string{key, fenceKey}, owner, ttl.Milliseconds())
+		if err != nil {
+			return 0, false, backendError(err)
+		}
+		return v.Int64(), v.Int64() > 0, nil
+	case c.dist != nil:
+		token, ok, err := c.dist.TryLock(ctx, key, fenceKey, owner, ttl)
+		return token, ok, backendError(err)
+	}
+	memoryLocks.Lock()
+	defer memoryLocks.Unlock()
+	now := time.Now()
+	// 未释放就过期的锁不会被删除，定期清理
+	if now.Sub(memoryLocks.sweptAt) >= memoryLockSweepInterval {
+		for k, held := range memoryLocks.held {
+			if !now.Before(held.expireAt) {
+				delete(memoryLocks.held, k)
+			}
+		}
+		memoryLocks.sweptAt = now
+	}
+	if held, ok := memoryLocks.held[key]; ok && now.Before(held.expireAt) {
+		return 0, false, nil
+	}
+	memoryLocks.fences[fenceKey]++
+	memoryLocks.held[key] = memoryLock{owner: owner, expireAt: now.Add(ttl)}
+	return memoryLocks.fences[fenceKey], true, nil
+}
+
+// renew 锁仍由owner持有时续期
+func (l *Locker) renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	c := l.c
+	switch {
+	case c.redis != nil:
+		v, err := redisRenewLockScript.Run(ctx, c.redis, []string{key}, owner, ttl.Milliseconds())
+		if err != nil {
+			return false, backendError(err)
+		}
+		return v.Int() == 1, nil
+	case c.dist != nil:
+		ok, err := c.dist.RenewLock(ctx, key, owner, ttl)
+		return ok, backendError(err)
+	}
+	memoryLocks.Lock()
+	defer memoryLocks.Unlock()
+	held, ok := memoryLocks.held[key]
+	if !ok || held.owner != owner || !time.Now().Before(held.expireAt) {
+		return false, nil
+	}
+	memoryLocks.held[key] = memoryLock{owner: owner, expireAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// unlock 锁仍由owner持有时释放
+func (l *Locker) unlock(ctx context.Context, key, owner string) (bool, error) {
+	c := l.c
+	switch {
+	case c.redis != nil:
+		return c.redisUnlock(ctx, key, owner)
+	case c.dist != nil:
+		ok, err := c.dist.Unlock(ctx, key, owner)
+		return ok, backendError(err)
+	}
+	memoryLocks.Lock()
+	defer memoryLocks.Unlock()
+	held, ok := memoryLocks.held[key]
+	if !ok {
+		return false, nil
+	}
+	// 已过期的锁同时删除
+	expired := !time.Now().Before(held.expireAt)
+	if expired || held.owner == owner {
+		delete(memoryLocks.held, key)
+	}
+	return !expired && held.owner == owner, nil
+}
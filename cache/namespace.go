@@ -0,0 +1,310 @@
+/*
+* @desc:按缓存前缀隔离的遍历和清空
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 15:40
+ */
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/tiger1103/gfast-cache/adapter"
+)
+
+// 内部使用的键前缀，不对外暴露
+const internalKeyPrefix = "__"
+
+// isInternalKey reports whether <key> without the cache prefix is used internally,
+// such as the tag indexes.
+func isInternalKey(key string) bool {
+	return strings.HasPrefix(key, tagKeyPrefix) || strings.HasPrefix(key, internalKeyPrefix)
+}
+
+// namespaceKeys returns the keys of the cache without the cache prefix, the internal keys,
+// the known absent entries and the expired entries kept for stale-if-error are excluded.
+func (c *GfCache) namespaceKeys(ctx context.Context) ([]string, error) {
+	if c.l2 != nil {
+		return c.l2.namespaceKeys(ctx)
+	}
+	if c.generation {
+		entries, err := c.genEntries(ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		return keys, nil
+	}
+	keys, hashes, err := c.scanNamespaceKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 与Data和Contains一致，过滤已知不存在和只为加载失败保留的过期条目
+	fullKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := hashes[key]; !ok {
+			fullKeys = append(fullKeys, c.CachePrefix+key)
+		}
+	}
+	values, err := c.getMany(ctx, fullKeys)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		live = make([]string, 0, len(keys))
+		now  = time.Now().UnixMilli()
+	)
+	for _, key := range keys {
+		// 哈希和读取前已删除的键没有值，保留
+		if v := values[c.CachePrefix+key]; v != nil && !v.IsNil() {
+			e, ok, err := decodeEntry(v)
+			if err != nil {
+				return nil, err
+			}
+			if ok && (e.Absent || e.isExpired(now)) {
+				continue
+			}
+		}
+		live = append(live, key)
+	}
+	return live, nil
+}
+
+// scanNamespaceKeys returns the keys of the cache without the cache prefix and the internal
+// keys, along with the hashes whose fields are saved as the sub keys by the dist cache.
+func (c *GfCache) scanNamespaceKeys(ctx context.Context) ([]string, map[string]struct{}, error) {
+	fullKeys, err := c.scanKeys(ctx, c.CachePrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		keys   = make([]string, 0, len(fullKeys))
+		hashes = make(map[string]struct{})
+	)
+	for _, fullKey := range fullKeys {
+		key := strings.TrimPrefix(fullKey, c.CachePrefix)
+		// 磁盘缓存哈希的字段保存为子键，只返回哈希的键
+		if i := strings.Index(key, adapter.HashFieldSep); i >= 0 {
+			if key = key[:i]; isInternalKey(key) {
+				continue
+			}
+			if _, ok := hashes[key]; !ok {
+				hashes[key] = struct{}{}
+				keys = append(keys, key)
+			}
+			continue
+		}
+		if !isInternalKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, hashes, nil
+}
+
+// namespaceData returns the key-value pairs of the cache, the keys are without
+// the cache prefix and the internal keys are excluded.
+func (c *GfCache) namespaceData(ctx context.Context) (map[string]interface{}, error) {
+	if c.l2 != nil {
+		return c.l2.namespaceData(ctx)
+	}
+	data := make(map[string]interface{})
+	if c.generation {
+		entries, err := c.genEntries(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for key, e := range entries {
+			data[key] = e.Value
+		}
+		return data, nil
+	}
+	raw, err := c.namespaceRawData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	for key, v := range raw {
+		e, ok, err := decodeEntry(gvar.New(v))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if e.Absent || e.isExpired(now) {
+				continue
+			}
+			v = e.Value
+		}
+		data[key] = v
+	}
+	return data, nil
+}
+
+// namespaceRawData returns the key-value pairs of the cache as they are in the backend.
+func (c *GfCache) namespaceRawData(ctx context.Context) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	switch {
+	case c.redis != nil:
+		keys, _, err := c.scanNamespaceKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		fullKeys := make([]string, len(keys))
+		for i, key := range keys {
+			fullKeys[i] = c.CachePrefix + key
+		}
+		values, err := c.getMany(ctx, fullKeys)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range keys {
+			// 其它类型的键或已过期的键没有值
+			if v := values[fullKeys[i]]; !v.IsNil() {
+				data[key] = v.Val()
+			}
+		}
+		return data, nil
+
+	case c.dist != nil:
+		all, err := c.dist.DataWithPrefix(ctx, c.CachePrefix)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		for fullKey, v := range all {
+			// 与redis一致，哈希没有值
+			if strings.Contains(fullKey, adapter.HashFieldSep) {
+				continue
+			}
+			if key := strings.TrimPrefix(fullKey, c.CachePrefix); !isInternalKey(key) {
+				data[key] = v
+			}
+		}
+		return data, nil
+	}
+	all, err := c.cache.Data(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	for k, v := range all {
+		fullKey := gconv.String(k)
+		if _, ok := v.(hashMap); ok || !strings.HasPrefix(fullKey, c.CachePrefix) {
+			continue
+		}
+		if key := strings.TrimPrefix(fullKey, c.CachePrefix); !isInternalKey(key) {
+			data[key] = v
+		}
+	}
+	return data, nil
+}
+
+// MGET的结果中不存在的键与空字符串无法区分，第一个元素标记各键是否存在
+var redisMGetScript = newRedisScript(`
+local values = redis.call('MGET', unpack(KEYS))
+local flags = {}
+local result = {''}
+for i = 1, #KEYS do
+	if values[i] then
+		flags[i] = '1'
+		result[i + 1] = values[i]
+	else
+		flags[i] = '0'
+		result[i + 1] = ''
+	end
+end
+result[1] = table.concat(flags)
+return result
+`)
+
+// getMany returns the values of <fullKeys>, the keys which do not exist are nil. The keys
+// of redis are read by MGET in batches and the keys of the dist cache in one transaction.
+func (c *GfCache) getMany(ctx context.Context, fullKeys []string) (map[string]*gvar.Var, error) {
+	values := make(map[string]*gvar.Var, len(fullKeys))
+	if c.redis != nil {
+		for start := 0; start < len(fullKeys); start += redisScanCount {
+			batch := fullKeys[start:min(start+redisScanCount, len(fullKeys))]
+			v, err := redisMGetScript.Run(ctx, c.redis, batch)
+			if err != nil {
+				return nil, backendError(err)
+			}
+			result := v.Strings()
+			if len(result) != len(batch)+1 {
+				return nil, decodeError(fmt.Errorf("invalid MGET result of %d keys", len(batch)))
+			}
+			for i, key := range batch {
+				if result[0][i] == '1' {
+					values[key] = gvar.New(result[i+1])
+				}
+			}
+		}
+		return values, nil
+	}
+	if c.dist != nil {
+		values, err := c.dist.GetMany(ctx, fullKeys)
+		return values, backendError(err)
+	}
+	for _, fullKey := range fullKeys {
+		v, err := c.cache.Get(ctx, fullKey)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		values[fullKey] = v
+	}
+	return values, nil
+}
+
+// ClearE deletes all keys of the cache prefix, including the tag indexes.
+// The keys of the other prefixes in the same backend are not affected, unless the cache
+// prefix is the beginning of them. It returns ErrEmptyPrefix if the cache prefix is empty.
+func (c *GfCache) ClearE(ctx context.Context) (err error) {
+	defer func() {
+		if err == nil {
+			err = c.publish(ctx, busOpClear, nil, "")
+		}
+	}()
+	if c.l2 != nil {
+		defer c.l1Clear(ctx)
+		return c.l2.ClearE(ctx)
+	}
+	// 空前缀会删除同一后端中所有前缀的键
+	if c.CachePrefix == "" {
+		return ErrEmptyPrefix
+	}
+	if c.redis != nil {
+		fullKeys, err := c.scanKeys(ctx, c.CachePrefix)
+		if err != nil {
+			return err
+		}
+		for start := 0; start < len(fullKeys); start += redisScanCount {
+			if _, err = c.redis.Del(ctx, fullKeys[start:min(start+redisScanCount, len(fullKeys))]...); err != nil {
+				return backendError(err)
+			}
+		}
+		return nil
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	if c.dist != nil {
+		return backendError(c.dist.RemoveWithPrefix(ctx, c.CachePrefix))
+	}
+	fullKeys, err := c.scanKeys(ctx, c.CachePrefix)
+	if err != nil || len(fullKeys) == 0 {
+		return err
+	}
+	_, err = c.cache.Remove(ctx, gconv.Interfaces(fullKeys)...)
+	return backendError(err)
+}
+
+// Clear deletes all keys of the cache prefix, including the tag indexes.
+// The keys of the other prefixes in the same backend are not affected, unless the cache
+// prefix is the beginning of them. Nothing is deleted if the cache prefix is empty.
+func (c *GfCache) Clear(ctx context.Context) {
+	c.logError(ctx, c.ClearE(ctx))
+}
@@ -0,0 +1,52 @@
+/*
+* @desc:缓存已知不存在的键(negative caching)
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 21:10
+ */
+
+package cache
+
+import "time"
+
+// 已知不存在的键的默认过期时间
+const defaultNegativeTTL = time.Minute
+
+// notFound is the type of NotFound.
+type notFound struct{}
+
+// NotFound is the sentinel value which the loader of GetOrSetFunc, GetOrSetFuncLock and
+// GetOrSetFuncSWR returns if the value does not exist in the data source, eg:
+//
+//	return cache.NotFound, nil
+//
+// The key is then cached as known absent for the negative TTL, see SetNegativeTTL.
+// During that time, the loader is not called for the key, the E methods of Get, Contains
+// and the GetOrSetFunc family return ErrAbsent and the others return nil or false.
+// Set or Remove the key to clear it.
+var NotFound interface{} = &notFound{}
+
+// SetNegativeTTL sets the duration for which the keys are cached as known absent, it should
+// be called before the cache is used. The default is one minute. It is never longer than
+// the duration passed to the GetOrSetFunc family.
+func (c *GfCache) SetNegativeTTL(ttl time.Duration) *GfCache {
+	if c.l2 != nil {
+		c.l2.SetNegativeTTL(ttl)
+		return c
+	}
+	c.negativeTTL = ttl
+	return c
+}
+
+// negativeDuration returns the duration of the known absent entry written by the loader
+// whose value expires after <duration>.
+func (c *GfCache) negativeDuration(duration time.Duration) time.Duration {
+	ttl := c.negativeTTL
+	if ttl <= 0 {
+		ttl = defaultNegativeTTL
+	}
+	if duration > 0 {
+		ttl = min(ttl, duration)
+	}
+	return ttl
+}
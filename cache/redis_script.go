@@ -0,0 +1,20 @@
+/*
+* @desc:redis lua脚本
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 11:40
+ */
+
+package cache
+
+import (
+	"github.com/tiger1103/gfast-cache/internal/redisscript"
+)
+
+// redisScript is a lua script executed by EVALSHA, it falls back to EVAL
+// if the script is not cached by the redis server yet.
+type redisScript = redisscript.Script
+
+func newRedisScript(src string) *redisScript {
+	return redisscript.New(src)
+}
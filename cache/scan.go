@@ -0,0 +1,80 @@
+/*
+* @desc:按前缀遍历缓存键
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 13:40
+ */
+
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gogf/gf/v2/database/gredis"
+)
+
+// redis SCAN 每批返回的建议数量
+const redisScanCount = 1000
+
+// scanKeys returns all keys of the backend starting with <prefix>, the keys contain the prefix.
+func (c *GfCache) scanKeys(ctx context.Context, prefix string, redisType ...string) ([]string, error) {
+	switch {
+	case c.redis != nil:
+		return c.redisScanKeys(ctx, prefix, redisType...)
+	case c.dist != nil:
+		keys, err := c.dist.KeysWithPrefix(ctx, prefix)
+		return keys, backendError(err)
+	}
+	allKeys, err := c.cache.KeyStrings(ctx)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	keys := make([]string, 0, len(allKeys))
+	for _, key := range allKeys {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// redisScanKeys scans the keys starting with <prefix> by SCAN, which does not block the redis
+// server like KEYS. The keys can be filtered by their redis data type.
+func (c *GfCache) redisScanKeys(ctx context.Context, prefix string, redisType ...string) ([]string, error) {
+	var (
+		keys   []string
+		cursor uint64
+		option = gredis.ScanOption{
+			Match: escapeRedisPattern(prefix) + "*",
+			Count: redisScanCount,
+		}
+	)
+	if len(redisType) > 0 {
+		option.Type = redisType[0]
+	}
+	for {
+		next, batch, err := c.redis.Scan(ctx, cursor, option)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		keys = append(keys, batch...)
+		if next == 0 {
+			return keys, nil
+		}
+		cursor = next
+	}
+}
+
+// escapeRedisPattern escapes the special characters of redis glob-style patterns.
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
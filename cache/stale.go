@@ -0,0 +1,53 @@
+/*
+* @desc:加载失败时返回过期值(stale-if-error)
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 19:50
+ */
+
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+)
+
+// SetStaleIfError sets the grace period during which the expired values written by
+// GetOrSetFunc, GetOrSetFuncLock and GetOrSetFuncSWR are kept, it should be called
+// before the cache is used. It is disabled if <grace> <= 0.
+//
+// The expired value is not returned by Get and the other reads. If the loader fails
+// during the grace period, the last known good value is returned along with an error
+// wrapping ErrStale and the loader error, so that the caller can tell it is stale by
+// errors.Is(err, ErrStale). The non-E methods log the error and return the stale value.
+func (c *GfCache) SetStaleIfError(grace time.Duration) *GfCache {
+	if c.l2 != nil {
+		c.l2.SetStaleIfError(grace)
+		return c
+	}
+	c.staleIfError = grace
+	return c
+}
+
+// withExpiry sets the logical expiry of <e> if stale-if-error or early expiration is
+// enabled, and returns the duration of the backend, which is <duration> plus the grace
+// period of stale-if-error.
+func (c *GfCache) withExpiry(e *entry, now time.Time, duration time.Duration) time.Duration {
+	if duration <= 0 || (c.staleIfError <= 0 && c.earlyBeta <= 0) {
+		return duration
+	}
+	e.ExpireAt = now.Add(duration).UnixMilli()
+	return duration + max(c.staleIfError, 0)
+}
+
+// staleFallback returns the value of the expired entry <stale> if the loading failed
+// with <err>, or else returns <v> and <err> as they are.
+func (c *GfCache) staleFallback(stale *entry, v *gvar.Var, err error) (*gvar.Var, error) {
+	// 加载函数确认不存在时不返回过期值
+	if err == nil || stale == nil || stale.Absent || errors.Is(err, ErrAbsent) {
+		return v, err
+	}
+	return gvar.New(stale.Value), staleError(err)
+}
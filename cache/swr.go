@@ -0,0 +1,106 @@
+/*
+* @desc:过期后先返回旧值再后台刷新(stale-while-revalidate)
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 18:10
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gcache"
+)
+
+// GetOrSetFuncSWRE returns the value of <key>, or sets <key> with result of function <f>
+// and returns its result if <key> does not exist in the cache.
+//
+// The value is fresh for <freshTTL> and then stale for <staleTTL>, after which it expires.
+// During the stale window, the stale value is returned right away and <f> is called in
+// a background goroutine to refresh it. Only the first load of <key> waits for <f>.
+// The soft expiry is stored next to the value, so it works with all the adapters.
+func (c *GfCache) GetOrSetFuncSWRE(ctx context.Context, key string, f gcache.Func, freshTTL, staleTTL time.Duration, tag ...string) (*gvar.Var, error) {
+	if freshTTL <= 0 {
+		return nil, gerror.Newf(`invalid fresh TTL "%s" of key "%s"`, freshTTL, key)
+	}
+	if c.l2 != nil {
+		// L1只缓存新鲜期，过期后由L2判断是否需要刷新
+		return c.tieredGetOrSet(ctx, key, freshTTL, func() (*gvar.Var, error) {
+			return c.l2.GetOrSetFuncSWRE(ctx, key, f, freshTTL, staleTTL, tag...)
+		})
+	}
+	var (
+		duration = freshTTL + max(staleTTL, 0)
+		now      = time.Now().UnixMilli()
+	)
+	e, err := c.lookupEntry(ctx, key)
+	switch {
+	case err == nil && e.Absent && !e.isExpired(now):
+		return nil, ErrAbsent
+	case err == nil && !e.isExpired(now):
+		if e.isStale(now) {
+			c.refresh(ctx, key, f, duration, freshTTL, tag)
+		}
+		return gvar.New(e.Value), nil
+	case err != nil && !errors.Is(err, ErrNotFound):
+		return nil, err
+	case err != nil && c.bloomRejects(ctx, key):
+		return nil, ErrAbsent
+	case err != nil:
+		e = nil
+	}
+	result, err, _ := c.loadFlight.Do(key, func() (interface{}, error) {
+		// 可能已被前一次加载写入
+		if e, err := c.getEntry(ctx, key); errors.Is(err, ErrAbsent) || !errors.Is(err, ErrNotFound) {
+			if err != nil {
+				return nil, err
+			}
+			return gvar.New(e.Value), nil
+		}
+		return c.loadValue(ctx, key, f, duration, freshTTL, tag)
+	})
+	v, _ := result.(*gvar.Var)
+	return c.staleFallback(e, v, err)
+}
+
+// GetOrSetFuncSWR returns the value of <key>, or sets <key> with result of function <f>
+// and returns its result if <key> does not exist in the cache.
+//
+// The value is fresh for <freshTTL> and then stale for <staleTTL>, after which it expires.
+// During the stale window, the stale value is returned right away and <f> is called in
+// a background goroutine to refresh it. Only the first load of <key> waits for <f>.
+func (c *GfCache) GetOrSetFuncSWR(ctx context.Context, key string, f gcache.Func, freshTTL, staleTTL time.Duration, tag ...string) *gvar.Var {
+	v, err := c.GetOrSetFuncSWRE(ctx, key, f, freshTTL, staleTTL, tag...)
+	c.logError(ctx, err)
+	return v
+}
+
+// refresh 后台刷新过期的值，同一个键同时只有一个刷新协程，刷新不受调用方ctx取消的影响
+func (c *GfCache) refresh(ctx context.Context, key string, f gcache.Func, duration, fresh time.Duration, tag []string) {
+	// 启动协程前标记，避免热点键在加载较慢时堆积协程
+	if _, running := c.refreshingSet.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer c.refreshingSet.Delete(key)
+		err := func() error {
+			// 可能已被前一次刷新更新
+			if e, err := c.getEntry(ctx, key); err != nil || !e.isStale(time.Now().UnixMilli()) {
+				return err
+			}
+			if _, err := c.loadValue(ctx, key, f, duration, fresh, tag); err != nil {
+				return err
+			}
+			return c.publish(ctx, busOpKeys, []string{key}, "")
+		}()
+		if err != nil {
+			c.logError(ctx, gerror.Wrapf(err, `refresh key "%s" failed`, key))
+		}
+	}()
+}
@@ -0,0 +1,348 @@
+/*
+* @desc:缓存标签索引，记录成员的过期时间并清理已过期的成员
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 13:10
+ */
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// tagIndex 标签索引，成员 => 过期时间(毫秒时间戳，0表示不过期)
+type tagIndex map[string]int64
+
+// tagIndexMarker 磁盘缓存中序列化后的标签索引以此开头，用于和普通缓存值区分
+var tagIndexMarker = []byte(`{"__gfcache_tags":`)
+
+// tagIndexJSON 磁盘缓存中标签索引的序列化格式
+type tagIndexJSON struct {
+	Members tagIndex `json:"__gfcache_tags"`
+}
+
+// expireAtOf returns the expiring timestamp in milliseconds for <duration>, 0 means never.
+func expireAtOf(duration time.Duration) int64 {
+	if duration <= 0 {
+		return 0
+	}
+	return time.Now().Add(duration).UnixMilli()
+}
+
+// prune deletes the expired members.
+func (idx tagIndex) prune(now int64) {
+	for member, expireAt := range idx {
+		if expireAt > 0 && expireAt <= now {
+			delete(idx, member)
+		}
+	}
+}
+
+// ttl returns the duration until the last member expires, 0 means never.
+func (idx tagIndex) ttl(now int64) time.Duration {
+	var last int64
+	for _, expireAt := range idx {
+		if expireAt == 0 {
+			return 0
+		}
+		if expireAt > last {
+			last = expireAt
+		}
+	}
+	return time.Duration(last-now) * time.Millisecond
+}
+
+// decodeTagIndex decodes the tag index, the former json array of keys is supported
+// and its members never expire.
+func decodeTagIndex(value *gvar.Var) (tagIndex, error) {
+	idx := make(tagIndex)
+	if value.IsNil() {
+		return idx, nil
+	}
+	data := value.Val()
+	if raw, ok := tagIndexBytes(data); ok {
+		var j tagIndexJSON
+		if err := json.Unmarshal(raw, &j); err != nil {
+			return nil, decodeError(err)
+		}
+		for member, expireAt := range j.Members {
+			idx[member] = expireAt
+		}
+		return idx, nil
+	}
+	switch v := data.(type) {
+	case tagIndex:
+		for member, expireAt := range v {
+			idx[member] = expireAt
+		}
+		return idx, nil
+	case string:
+		data = nil
+		if err := json.Unmarshal([]byte(v), &data); err != nil {
+			return nil, decodeError(err)
+		}
+	case []byte:
+		data = nil
+		if err := json.Unmarshal(v, &data); err != nil {
+			return nil, decodeError(err)
+		}
+	}
+	switch v := data.(type) {
+	case []interface{}:
+		for _, member := range v {
+			idx[gconv.String(member)] = 0
+		}
+	case map[string]interface{}:
+		for member, expireAt := range v {
+			idx[member] = gconv.Int64(expireAt)
+		}
+	default:
+		return nil, decodeError(fmt.Errorf(`invalid tag index type "%T"`, data))
+	}
+	return idx, nil
+}
+
+// tagIndexBytes returns the serialized tag index in <data>, it returns false if <data> is
+// not serialized by saveTagIndex.
+func tagIndexBytes(data interface{}) ([]byte, bool) {
+	var raw []byte
+	switch v := data.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return nil, false
+	}
+	return raw, bytes.HasPrefix(raw, tagIndexMarker)
+}
+
+// isTagIndex reports whether <value> is a tag index saved by saveTagIndex, rather than
+// a cached value under the key prefix of the tag indexes.
+func isTagIndex(value *gvar.Var) bool {
+	if _, ok := value.Val().(tagIndex); ok {
+		return true
+	}
+	_, ok := tagIndexBytes(value.Val())
+	return ok
+}
+
+// 读取索引
+func (c *GfCache) loadTagIndex(ctx context.Context, indexKey string) (tagIndex, error) {
+	value, err := c.cache.Get(ctx, indexKey)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	return decodeTagIndex(value)
+}
+
+// 保存索引，清理过期成员，索引随最后一个成员过期
+func (c *GfCache) saveTagIndex(ctx context.Context, indexKey string, idx tagIndex) error {
+	now := time.Now().UnixMilli()
+	idx.prune(now)
+	if len(idx) == 0 {
+		_, err := c.cache.Remove(ctx, indexKey)
+		return backendError(err)
+	}
+	var value interface{} = idx
+	// 磁盘缓存按json保存，加上标记以便和普通缓存值区分
+	if c.dist != nil {
+		data, err := json.Marshal(tagIndexJSON{Members: idx})
+		if err != nil {
+			return decodeError(err)
+		}
+		value = string(data)
+	}
+	return backendError(c.cache.Set(ctx, indexKey, value, idx.ttl(now)))
+}
+
+// 设置tag缓存的keys，同时记录key所属的tags
+func (c *GfCache) cacheTagKey(ctx context.Context, key string, duration time.Duration, tag ...string) error {
+	var (
+		expireAt = expireAtOf(duration)
+		tags     = make([]string, 0, len(tag))
+	)
+	for _, t := range tag {
+		// 空标签表示不使用标签，其索引键与缓存前缀相同，不能登记
+		if t == "" {
+			continue
+		}
+		tags = append(tags, t)
+		if err := c.addIndexMembers(ctx, c.CachePrefix+c.setTagKey(t), expireAt, key); err != nil {
+			return err
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return c.addIndexMembers(ctx, c.CachePrefix+c.setKeyTagKey(key), expireAt, tags...)
+}
+
+// 批量设置tag缓存的keys，每个key使用各自的过期时间
+func (c *GfCache) cacheTagKeys(ctx context.Context, durations map[string]time.Duration, tag ...string) error {
+	tags := make([]string, 0, len(tag))
+	for _, t := range tag {
+		if t != "" {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	// 每个标签索引只读写一次
+	for _, t := range tags {
+		indexKey := c.CachePrefix + c.setTagKey(t)
+		idx, err := c.loadTagIndex(ctx, indexKey)
+		if err != nil {
+			return err
+		}
+		for key, duration := range durations {
+			idx[key] = expireAtOf(duration)
+		}
+		if err = c.saveTagIndex(ctx, indexKey, idx); err != nil {
+			return err
+		}
+	}
+	for key, duration := range durations {
+		if err := c.addIndexMembers(ctx, c.CachePrefix+c.setKeyTagKey(key), expireAtOf(duration), tags...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 向索引中添加成员
+func (c *GfCache) addIndexMembers(ctx context.Context, indexKey string, expireAt int64, members ...string) error {
+	idx, err := c.loadTagIndex(ctx, indexKey)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		idx[member] = expireAt
+	}
+	return c.saveTagIndex(ctx, indexKey, idx)
+}
+
+// 从索引中删除成员，索引为空时删除索引
+func (c *GfCache) removeIndexMember(ctx context.Context, indexKey string, member string) error {
+	idx, err := c.loadTagIndex(ctx, indexKey)
+	if err != nil {
+		return err
+	}
+	if _, ok := idx[member]; !ok {
+		return nil
+	}
+	delete(idx, member)
+	return c.saveTagIndex(ctx, indexKey, idx)
+}
+
+// 删除标签下的缓存
+func (c *GfCache) removeByTag(ctx context.Context, tag string) error {
+	tagKey := c.CachePrefix + c.setTagKey(tag)
+	idx, err := c.loadTagIndex(ctx, tagKey)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(idx))
+	for key := range idx {
+		if err = c.removeKeyTags(ctx, key, tag); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	if err = c.removeKeys(ctx, keys); err != nil {
+		return err
+	}
+	_, err = c.cache.Remove(ctx, tagKey)
+	return backendError(err)
+}
+
+// 清理key在其它标签中的索引
+func (c *GfCache) removeKeyTags(ctx context.Context, key string, exceptTag string) error {
+	keyTagKey := c.CachePrefix + c.setKeyTagKey(key)
+	idx, err := c.loadTagIndex(ctx, keyTagKey)
+	if err != nil {
+		return err
+	}
+	for t := range idx {
+		if t == exceptTag {
+			continue
+		}
+		if err = c.removeIndexMember(ctx, c.CachePrefix+c.setTagKey(t), key); err != nil {
+			return err
+		}
+	}
+	_, err = c.cache.Remove(ctx, keyTagKey)
+	return backendError(err)
+}
+
+// CompactTags deletes the expired members from all tag indexes of the cache.
+// The indexes whose members are all expired are deleted.
+func (c *GfCache) CompactTags(ctx context.Context) error {
+	if c.l2 != nil {
+		return c.l2.CompactTags(ctx)
+	}
+	// 版本号模式下不维护标签索引
+	if c.generation {
+		return nil
+	}
+	if c.redis != nil {
+		return c.redisCompactTags(ctx)
+	}
+	c.tagSetMux.Lock()
+	defer c.tagSetMux.Unlock()
+	tagKeys, err := c.scanKeys(ctx, c.CachePrefix+tagKeyPrefix)
+	if err != nil {
+		return err
+	}
+	for _, tagKey := range tagKeys {
+		value, err := c.cache.Get(ctx, tagKey)
+		if err != nil {
+			return backendError(err)
+		}
+		// 只处理标签索引，旧格式的索引在下次写入时转换，同一前缀下的普通缓存不受影响
+		if !isTagIndex(value) {
+			continue
+		}
+		idx, err := decodeTagIndex(value)
+		if err != nil {
+			return err
+		}
+		if err = c.saveTagIndex(ctx, tagKey, idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartCompactTags calls CompactTags every <interval> in background until <ctx> is done.
+// It returns ErrInvalidInterval if <interval> is not positive.
+func (c *GfCache) StartCompactTags(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return ErrInvalidInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.CompactTags(ctx); err != nil {
+					g.Log().Error(ctx, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+* @desc:层级标签及通配符匹配
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 15:10
+ */
+
+package cache
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+const (
+	tagPathSeparator = "/"                    // 层级标签的分隔符
+	tagSubtreeSuffix = tagPathSeparator + "*" // 匹配子树的后缀
+	tagPatternChars  = "*?[\\"                // 通配符
+)
+
+// checkTagPattern returns an error if <pattern> is malformed.
+func checkTagPattern(pattern string) error {
+	if _, err := path.Match(strings.TrimSuffix(pattern, tagSubtreeSuffix), ""); err != nil {
+		return gerror.Wrapf(err, `invalid tag pattern "%s"`, pattern)
+	}
+	return nil
+}
+
+// matchTag reports whether <tag> matches <pattern>.
+// The pattern ending with "/*" matches its root and all the descendants, such as "org:1/*"
+// matches "org:1", "org:1/dept:3" and "org:1/dept:3/user:9". The other wildcards follow
+// path.Match, in which "*" does not match the separator "/".
+func matchTag(pattern, tag string) bool {
+	root, subtree := strings.CutSuffix(pattern, tagSubtreeSuffix)
+	if !subtree {
+		ok, _ := path.Match(pattern, tag)
+		return ok
+	}
+	for i := 0; i <= len(tag); i++ {
+		if i < len(tag) && tag[i] != tagPathSeparator[0] {
+			continue
+		}
+		if ok, _ := path.Match(root, tag[:i]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTags returns the tags of the cache matching <pattern>.
+func (c *GfCache) matchTags(ctx context.Context, pattern string) ([]string, error) {
+	if err := checkTagPattern(pattern); err != nil {
+		return nil, err
+	}
+	// 按通配符之前的部分缩小扫描范围
+	literal := strings.TrimSuffix(pattern, tagSubtreeSuffix)
+	if i := strings.IndexAny(literal, tagPatternChars); i >= 0 {
+		literal = literal[:i]
+	}
+	prefix := c.tagListPrefix()
+	tagKeys, err := c.scanTagKeys(ctx, prefix+literal)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(tagKeys))
+	for _, tagKey := range tagKeys {
+		if tag := strings.TrimPrefix(tagKey, prefix); matchTag(pattern, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// RemoveByTagPatternE deletes the keys of all tags matching <pattern>, and the tags themselves.
+// For example, "org:1/*" removes the tag "org:1" and all the tags under it.
+func (c *GfCache) RemoveByTagPatternE(ctx context.Context, pattern string) (err error) {
+	defer func() {
+		if err == nil {
+			err = c.publish(ctx, busOpPattern, nil, pattern)
+		}
+	}()
+	if c.l2 != nil {
+		defer c.l1Clear(ctx)
+		return c.l2.RemoveByTagPatternE(ctx, pattern)
+	}
+	tags, err := c.matchTags(ctx, pattern)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err = c.removeTag(ctx, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveByTagPattern deletes the keys of all tags matching <pattern>, and the tags themselves.
+// For example, "org:1/*" removes the tag "org:1" and all the tags under it.
+func (c *GfCache) RemoveByTagPattern(ctx context.Context, pattern string) {
+	c.logError(ctx, c.RemoveByTagPatternE(ctx, pattern))
+}
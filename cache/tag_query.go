@@ -0,0 +1,195 @@
+/*
+* @desc:缓存标签查询
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 14:30
+ */
+
+package cache
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// tagListPrefix returns the key prefix for listing the tags.
+func (c *GfCache) tagListPrefix() string {
+	if c.generation {
+		return c.CachePrefix + tagGenKeyPrefix
+	}
+	return c.CachePrefix + tagKeyPrefix
+}
+
+// scanTagKeys returns the keys of the tags starting with <prefix>, the cached values under
+// the key prefix of the tag indexes are excluded.
+func (c *GfCache) scanTagKeys(ctx context.Context, prefix string) ([]string, error) {
+	switch {
+	case c.generation:
+		return c.scanKeys(ctx, prefix)
+	case c.redis != nil:
+		// 只处理有序集合，与CompactTags一致
+		return c.scanKeys(ctx, prefix, "zset")
+	}
+	keys, err := c.scanKeys(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	values, err := c.getMany(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	tagKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if v := values[key]; v != nil && isTagIndex(v) {
+			tagKeys = append(tagKeys, key)
+		}
+	}
+	return tagKeys, nil
+}
+
+// ListTagsE returns all tags of the cache in ascending order. In generation mode, they are
+// the tags of the valid entries.
+func (c *GfCache) ListTagsE(ctx context.Context) ([]string, error) {
+	if c.l2 != nil {
+		return c.l2.ListTagsE(ctx)
+	}
+	if c.generation {
+		tags, err := c.genTags(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(tags)
+		return tags, nil
+	}
+	prefix := c.tagListPrefix()
+	tagKeys, err := c.scanTagKeys(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, len(tagKeys))
+	for i, tagKey := range tagKeys {
+		tags[i] = strings.TrimPrefix(tagKey, prefix)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// KeysByTagE returns the unexpired keys of <tag> in ascending order.
+func (c *GfCache) KeysByTagE(ctx context.Context, tag string) ([]string, error) {
+	if c.l2 != nil {
+		return c.l2.KeysByTagE(ctx, tag)
+	}
+	tagKey := c.CachePrefix + c.setTagKey(tag)
+	var keys []string
+	if c.generation {
+		var err error
+		if keys, err = c.genKeysByTag(ctx, tag); err != nil {
+			return nil, err
+		}
+	} else if c.redis != nil {
+		v, err := redisTagMembersScript.Run(ctx, c.redis, []string{tagKey}, 0)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		keys = v.Strings()
+	} else {
+		c.tagSetMux.Lock()
+		idx, err := c.loadTagIndex(ctx, tagKey)
+		c.tagSetMux.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		idx.prune(time.Now().UnixMilli())
+		keys = make([]string, 0, len(idx))
+		for key := range idx {
+			keys = append(keys, key)
+		}
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// TagSizeE returns the number of the unexpired keys of <tag>.
+func (c *GfCache) TagSizeE(ctx context.Context, tag string) (int, error) {
+	if c.l2 != nil {
+		return c.l2.TagSizeE(ctx, tag)
+	}
+	if c.redis != nil && !c.generation {
+		v, err := redisTagMembersScript.Run(ctx, c.redis, []string{c.CachePrefix + c.setTagKey(tag)}, 1)
+		return v.Int(), backendError(err)
+	}
+	keys, err := c.KeysByTagE(ctx, tag)
+	return len(keys), err
+}
+
+// TagsOfKeyE returns the tags which <key> belongs to in ascending order.
+func (c *GfCache) TagsOfKeyE(ctx context.Context, key string) ([]string, error) {
+	if c.l2 != nil {
+		return c.l2.TagsOfKeyE(ctx, key)
+	}
+	keyTagKey := c.CachePrefix + c.setKeyTagKey(key)
+	var tags []string
+	if c.generation {
+		var err error
+		if tags, err = c.genTagsOfKey(ctx, key); err != nil {
+			return nil, err
+		}
+	} else if c.redis != nil {
+		v, err := c.redis.SMembers(ctx, keyTagKey)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		tags = gconv.Strings(v.Interfaces())
+	} else {
+		c.tagSetMux.Lock()
+		idx, err := c.loadTagIndex(ctx, keyTagKey)
+		c.tagSetMux.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		tags = make([]string, 0, len(idx))
+		for tag := range idx {
+			tags = append(tags, tag)
+		}
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// ListTags returns all tags of the cache in ascending order.
+func (c *GfCache) ListTags(ctx context.Context) []string {
+	v, err := c.ListTagsE(ctx)
+	c.logError(ctx, err)
+	return v
+}
+
+// KeysByTag returns the unexpired keys of <tag> in ascending order.
+func (c *GfCache) KeysByTag(ctx context.Context, tag string) []string {
+	v, err := c.KeysByTagE(ctx, tag)
+	c.logError(ctx, err)
+	return v
+}
+
+// TagSize returns the number of the unexpired keys of <tag>.
+func (c *GfCache) TagSize(ctx context.Context, tag string) int {
+	v, err := c.TagSizeE(ctx, tag)
+	c.logError(ctx, err)
+	return v
+}
+
+// TagsOfKey returns the tags which <key> belongs to in ascending order.
+func (c *GfCache) TagsOfKey(ctx context.Context, key string) []string {
+	v, err := c.TagsOfKeyE(ctx, key)
+	c.logError(ctx, err)
+	return v
+}
@@ -0,0 +1,291 @@
+/*
+* @desc:redis缓存标签，使用redis有序集合保存标签索引并通过lua脚本原子更新
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 11:50
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/os/gcache"
+)
+
+// 标签索引使用有序集合保存，成员的分值为其过期时间(毫秒时间戳，+inf表示不过期)。
+// toZSet 将旧版本以json数组字符串或集合保存的标签索引转换为有序集合；
+// refresh 清理已过期的成员，并使索引随最后一个成员过期；
+// addTags 将缓存键加入KEYS[firstKey...]的标签索引，标签名为ARGV[firstArg...]。
+const redisLuaTagIndex = `
+local function toZSet(k)
+	local t = redis.call('TYPE', k).ok
+	if t == 'string' then
+		local ok, members = pcall(cjson.decode, redis.call('GET', k))
+		redis.call('DEL', k)
+		if ok and type(members) == 'table' then
+			for _, m in ipairs(members) do
+				redis.call('ZADD', k, '+inf', tostring(m))
+			end
+		end
+	elseif t == 'set' then
+		local members = redis.call('SMEMBERS', k)
+		redis.call('DEL', k)
+		for _, m in ipairs(members) do
+			redis.call('ZADD', k, '+inf', m)
+		end
+	end
+end
+local function nowMs()
+	local t = redis.call('TIME')
+	return tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+end
+local function refresh(k, now)
+	redis.call('ZREMRANGEBYSCORE', k, '-inf', now)
+	if redis.call('ZCOUNT', k, '+inf', '+inf') > 0 then
+		redis.call('PERSIST', k)
+	else
+		local last = redis.call('ZRANGE', k, -1, -1, 'WITHSCORES')
+		if last[2] then
+			redis.call('PEXPIREAT', k, last[2])
+		end
+	end
+end
+local function addTags(keyTagKey, key, ttl, firstKey, firstArg)
+	local now = nowMs()
+	local expireAt = '+inf'
+	if ttl > 0 then
+		expireAt = now + ttl
+	end
+	for i = firstKey, #KEYS do
+		toZSet(KEYS[i])
+		redis.call('ZADD', KEYS[i], expireAt, key)
+		refresh(KEYS[i], now)
+		redis.call('SADD', keyTagKey, ARGV[firstArg + i - firstKey])
+	end
+	if #KEYS >= firstKey then
+		if ttl > 0 then
+			redis.call('PEXPIRE', keyTagKey, ttl)
+		else
+			redis.call('PERSIST', keyTagKey)
+		end
+	end
+end
+`
+
+const (
+	redisSetModeSet      = 0 // 直接写入
+	redisSetModeNotExist = 1 // 不存在时写入
+	redisSetModeGetOrSet = 2 // 存在时返回{1, 旧值}，否则写入并返回{0}
+)
+
+// KEYS[1] 缓存键, KEYS[2] 缓存所属标签的集合, KEYS[3...] 标签索引
+// ARGV[1] 缓存值, ARGV[2] 过期毫秒数, ARGV[3] 写入模式, ARGV[4] 缓存键名(不含前缀), ARGV[5...] 标签
+// 只为加载失败保留的过期条目和已知不存在的条目视为不存在
+var redisSetWithTagsScript = newRedisScript(redisLuaTagIndex + `
+local function deadEntry(v)
+	if string.sub(v, 1, 13) ~= '{"__gfcache":' then
+		return false
+	end
+	local ok, e = pcall(cjson.decode, v)
+	if not ok or type(e) ~= 'table' then
+		return false
+	end
+	if e.a then
+		return true
+	end
+	local expireAt = tonumber(e.ea)
+	return expireAt ~= nil and expireAt > 0 and nowMs() >= expireAt
+end
+local mode = tonumber(ARGV[3])
+if mode ~= 0 and redis.call('EXISTS', KEYS[1]) == 1 then
+	local old = false
+	if redis.call('TYPE', KEYS[1]).ok == 'string' then
+		old = redis.call('GET', KEYS[1])
+	end
+	if not (old and deadEntry(old)) then
+		if mode == 2 then
+			return {1, old}
+		end
+		return 0
+	end
+end
+local args = {'SET', KEYS[1], ARGV[1]}
+if tonumber(ARGV[2]) > 0 then
+	table.insert(args, 'PX')
+	table.insert(args, ARGV[2])
+end
+redis.call(unpack(args))
+addTags(KEYS[2], ARGV[4], tonumber(ARGV[2]), 3, 5)
+if mode == 2 then
+	return {0}
+end
+return 1
+`)
+
+// KEYS[1] 标签索引
+// ARGV[1] 缓存所属标签集合的键前缀, ARGV[2] 标签索引的键前缀, ARGV[3] 缓存键前缀, ARGV[4] 标签
+var redisRemoveByTagScript = newRedisScript(redisLuaTagIndex + `
+toZSet(KEYS[1])
+local keys = redis.call('ZRANGE', KEYS[1], 0, -1)
+for _, k in ipairs(keys) do
+	local keyTagKey = ARGV[1] .. k
+	for _, t in ipairs(redis.call('SMEMBERS', keyTagKey)) do
+		if t ~= ARGV[4] then
+			toZSet(ARGV[2] .. t)
+			redis.call('ZREM', ARGV[2] .. t, k)
+		end
+	end
+	redis.call('DEL', keyTagKey, ARGV[3] .. k)
+end
+redis.call('DEL', KEYS[1])
+return #keys
+`)
+
+// KEYS[...] 缓存键
+// ARGV[1] 缓存所属标签集合的键前缀, ARGV[2] 标签索引的键前缀, ARGV[3...] 缓存键名(不含前缀)
+var redisRemoveScript = newRedisScript(redisLuaTagIndex + `
+local last
+for i = 1, #KEYS do
+	local k = ARGV[i + 2]
+	local keyTagKey = ARGV[1] .. k
+	for _, t in ipairs(redis.call('SMEMBERS', keyTagKey)) do
+		toZSet(ARGV[2] .. t)
+		redis.call('ZREM', ARGV[2] .. t, k)
+	end
+	if i == #KEYS then
+		last = redis.pcall('GET', KEYS[i])
+		if type(last) == 'table' and last.err then
+			last = false
+		end
+	end
+	redis.call('DEL', keyTagKey, KEYS[i])
+end
+return last
+`)
+
+// KEYS[1] 标签索引
+// ARGV[1] 为1时返回成员数量，否则返回成员列表
+var redisTagMembersScript = newRedisScript(redisLuaTagIndex + `
+toZSet(KEYS[1])
+local min = '(' .. nowMs()
+if ARGV[1] == '1' then
+	return redis.call('ZCOUNT', KEYS[1], min, '+inf')
+end
+return redis.call('ZRANGEBYSCORE', KEYS[1], min, '+inf')
+`)
+
+// KEYS[1] 标签索引
+var redisCompactTagScript = newRedisScript(redisLuaTagIndex + `
+refresh(KEYS[1], nowMs())
+return redis.call('ZCARD', KEYS[1])
+`)
+
+// redisSetWithTags writes <key>-<value> pair and registers it under <tags> atomically.
+// For mode redisSetModeGetOrSet, it returns the existing value if <key> exists.
+func (c *GfCache) redisSetWithTags(ctx context.Context, mode int, key string, value interface{}, duration time.Duration, tags []string) (*gvar.Var, error) {
+	keys := []string{c.CachePrefix + key, c.CachePrefix + c.setKeyTagKey(key)}
+	args := []interface{}{value, duration.Milliseconds(), mode, key}
+	for _, t := range tags {
+		if t == "" {
+			continue
+		}
+		keys = append(keys, c.CachePrefix+c.setTagKey(t))
+		args = append(args, t)
+	}
+	v, err := redisSetWithTagsScript.Run(ctx, c.redis, keys, args...)
+	return v, backendError(err)
+}
+
+// redisRemoveByTag deletes the keys of <tag> and cleans up their other tags atomically.
+func (c *GfCache) redisRemoveByTag(ctx context.Context, tag string) error {
+	_, err := redisRemoveByTagScript.Run(ctx, c.redis,
+		[]string{c.CachePrefix + c.setTagKey(tag)},
+		c.CachePrefix+keyTagKeyPrefix, c.CachePrefix+tagKeyPrefix, c.CachePrefix, tag,
+	)
+	return backendError(err)
+}
+
+// redisRemove deletes <keys> and removes them from the indexes of their tags atomically,
+// it returns the value of the last key.
+func (c *GfCache) redisRemove(ctx context.Context, keys ...string) (*gvar.Var, error) {
+	var (
+		redisKeys = make([]string, len(keys))
+		args      = make([]interface{}, 0, len(keys)+2)
+	)
+	args = append(args, c.CachePrefix+keyTagKeyPrefix, c.CachePrefix+tagKeyPrefix)
+	for i, key := range keys {
+		redisKeys[i] = c.CachePrefix + key
+		args = append(args, key)
+	}
+	v, err := redisRemoveScript.Run(ctx, c.redis, redisKeys, args...)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	if v.IsNil() {
+		return nil, nil
+	}
+	return unwrapVar(v)
+}
+
+// redisSetE implements SetE for redis.
+func (c *GfCache) redisSetE(ctx context.Context, key string, value interface{}, duration time.Duration, tag []string) error {
+	if len(tag) == 0 || value == nil || duration < 0 {
+		return backendError(c.cache.Set(ctx, c.CachePrefix+key, value, duration))
+	}
+	_, err := c.redisSetWithTags(ctx, redisSetModeSet, key, value, duration, tag)
+	return err
+}
+
+// redisSetIfNotExistE implements SetIfNotExistE for redis.
+func (c *GfCache) redisSetIfNotExistE(ctx context.Context, key string, value interface{}, duration time.Duration, tag []string) (bool, error) {
+	if f, ok := value.(gcache.Func); ok {
+		var err error
+		if value, err = f(ctx); err != nil {
+			return false, err
+		}
+	}
+	if value == nil || duration < 0 {
+		v, err := c.cache.SetIfNotExist(ctx, c.CachePrefix+key, value, duration)
+		return v, backendError(err)
+	}
+	v, err := c.redisSetWithTags(ctx, redisSetModeNotExist, key, value, duration, tag)
+	if err != nil {
+		return false, err
+	}
+	return v.Int() == 1, nil
+}
+
+// redisGetOrSetE implements GetOrSetE for redis.
+func (c *GfCache) redisGetOrSetE(ctx context.Context, key string, value interface{}, duration time.Duration, tag []string) (*gvar.Var, error) {
+	if value == nil || duration < 0 {
+		v, err := c.cache.GetOrSet(ctx, c.CachePrefix+key, value, duration)
+		return v, backendError(err)
+	}
+	v, err := c.redisSetWithTags(ctx, redisSetModeGetOrSet, key, value, duration, tag)
+	if err != nil {
+		return nil, err
+	}
+	result := v.Vars()
+	if len(result) > 1 && result[0].Int() == 1 {
+		return unwrapVar(result[1])
+	}
+	return gvar.New(value), nil
+}
+
+// redisCompactTags deletes the expired members from all tag indexes of the cache.
+func (c *GfCache) redisCompactTags(ctx context.Context) error {
+	// 只处理有序集合，旧格式的索引在下次写入时转换
+	tagKeys, err := c.scanKeys(ctx, c.CachePrefix+tagKeyPrefix, "zset")
+	if err != nil {
+		return err
+	}
+	for _, tagKey := range tagKeys {
+		if _, err = redisCompactTagScript.Run(ctx, c.redis, []string{tagKey}); err != nil {
+			return backendError(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+/*
+* @desc:二级缓存，进程内的L1缓存位于redis或磁盘缓存之前
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 17:00
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/os/gcache"
+	"github.com/tiger1103/gfast-cache/instance"
+)
+
+// L1 默认的过期时间
+const defaultL1TTL = 10 * time.Second
+
+// L1Options L1缓存配置
+type L1Options struct {
+	Size int           // L1最多保存的键数量，超出时按LRU淘汰，0表示不限制
+	TTL  time.Duration // L1的过期时间，不超过写入时的过期时间，默认10秒
+}
+
+// NewTiered creates a two-level cache, in which a bounded in-memory L1 is in front of
+// <l2>, the redis or dist cache. The <prefix> identifies the tiered cache instance.
+//
+// The values read from L2 are promoted into L1, and Set writes both levels. Remove
+// deletes the key from both levels, and RemoveByTag and the other bulk invalidations
+// clear the whole L1 as it does not record the tags. L1 is local to the process, the
+// other instances see the changes after their L1 expires.
+func NewTiered(prefix string, l1Opts L1Options, l2 *GfCache) *GfCache {
+	instanceKey := fmt.Sprintf("%s.%s", prefix, "tiered")
+	cache := instance.GetOrSetFuncLock(instanceKey, func() interface{} {
+		if l1Opts.TTL <= 0 {
+			l1Opts.TTL = defaultL1TTL
+		}
+		l1 := gcache.New()
+		if l1Opts.Size > 0 {
+			l1 = gcache.New(l1Opts.Size)
+		}
+		cache := &GfCache{
+			CachePrefix: prefix,
+			l1:          l1,
+			l1TTL:       l1Opts.TTL,
+			l2:          l2,
+		}
+		return cache
+	})
+	return cache.(*GfCache)
+}
+
+// l1Duration returns the TTL in L1 for the value expiring after <duration>.
+func (c *GfCache) l1Duration(duration time.Duration) time.Duration {
+	if duration > 0 && duration < c.l1TTL {
+		return duration
+	}
+	return c.l1TTL
+}
+
+// l1Get returns the value of <key> in L1, or nil if it does not exist.
+func (c *GfCache) l1Get(ctx context.Context, key string) *gvar.Var {
+	v, _ := c.l1.Get(ctx, c.CachePrefix+key)
+	return v
+}
+
+// l1Set writes <v> into L1.
+func (c *GfCache) l1Set(ctx context.Context, key string, v interface{}, duration time.Duration) {
+	if v == nil || duration < 0 {
+		c.l1Remove(ctx, key)
+		return
+	}
+	_ = c.l1.Set(ctx, c.CachePrefix+key, v, c.l1Duration(duration))
+}
+
+// l1Remove deletes <keys> from L1.
+func (c *GfCache) l1Remove(ctx context.Context, keys ...string) {
+	l1Keys := make([]interface{}, len(keys))
+	for i, key := range keys {
+		l1Keys[i] = c.CachePrefix + key
+	}
+	_, _ = c.l1.Remove(ctx, l1Keys...)
+}
+
+// l1Clear deletes all keys in L1.
+func (c *GfCache) l1Clear(ctx context.Context) {
+	_ = c.l1.Clear(ctx)
+}
+
+// tieredGet implements GetE for the tiered cache.
+func (c *GfCache) tieredGet(ctx context.Context, key string) (*gvar.Var, error) {
+	if v := c.l1Get(ctx, key); !v.IsNil() {
+		return v, nil
+	}
+	e, err := c.l2.getEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	// L1中的值不晚于L2过期
+	ttl, err := c.l2.remainingTTL(ctx, key, e)
+	if err != nil {
+		return nil, err
+	}
+	c.l1Set(ctx, key, e.Value, ttl)
+	return gvar.New(e.Value), nil
+}
+
+// remainingTTL returns the remaining time to live of <key> whose entry is <e>. It returns 0
+// if <key> does not expire, and a negative duration if it no longer exists.
+func (c *GfCache) remainingTTL(ctx context.Context, key string, e *entry) (time.Duration, error) {
+	// 后端的过期时间包含加载失败时的宽限期，以逻辑过期时间为准
+	if e.ExpireAt > 0 {
+		return time.Until(time.UnixMilli(e.ExpireAt)), nil
+	}
+	fullKey, err := c.casKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	ttl, err := c.cache.GetExpire(ctx, fullKey)
+	return ttl, backendError(err)
+}
+
+// tieredSet implements SetE for the tiered cache.
+func (c *GfCache) tieredSet(ctx context.Context, key string, value interface{}, duration time.Duration, tag []string) error {
+	if err := c.l2.SetE(ctx, key, value, duration, tag...); err != nil {
+		c.l1Remove(ctx, key)
+		return err
+	}
+	c.l1Set(ctx, key, value, duration)
+	return nil
+}
+
+// tieredGetOrSet implements GetOrSetE, GetOrSetFuncE and GetOrSetFuncLockE for the
+// tiered cache, the result of <f> is promoted into L1.
+func (c *GfCache) tieredGetOrSet(ctx context.Context, key string, duration time.Duration, f func() (*gvar.Var, error)) (*gvar.Var, error) {
+	if v := c.l1Get(ctx, key); !v.IsNil() {
+		return v, nil
+	}
+	v, err := f()
+	if errors.Is(err, ErrStale) {
+		// 过期值不进入L1
+		return v, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !v.IsNil() {
+		c.l1Set(ctx, key, v.Val(), duration)
+	}
+	return v, nil
+}
@@ -0,0 +1,157 @@
+/*
+* @desc:类型化缓存
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 9:30
+ */
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+// Typed is a type-safe facade over GfCache. It decodes the cached values into <T>
+// the same way for the memory, redis and dist adapters, so that callers need not
+// convert the returned *gvar.Var by themselves.
+type Typed[T any] struct {
+	cache *GfCache
+}
+
+// NewTyped creates and returns a typed facade over cache <c>.
+func NewTyped[T any](c *GfCache) *Typed[T] {
+	return &Typed[T]{
+		cache: c,
+	}
+}
+
+// Cache returns the underlying GfCache.
+func (t *Typed[T]) Cache() *GfCache {
+	return t.cache
+}
+
+// Get returns the value of <key> decoded as <T>.
+// The returned <ok> is false if <key> does not exist in the cache.
+func (t *Typed[T]) Get(ctx context.Context, key string) (value T, ok bool, err error) {
+	v, err := t.cache.GetE(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return value, false, nil
+	}
+	if err != nil {
+		return
+	}
+	value, err = decodeVar[T](v)
+	return value, err == nil, err
+}
+
+// Set sets cache with <key>-<value> pair, which is expired after <duration>.
+// It does not expire if <duration> <= 0.
+func (t *Typed[T]) Set(ctx context.Context, key string, value T, duration time.Duration, tag ...string) error {
+	return t.cache.SetE(ctx, key, value, duration, tag...)
+}
+
+// GetOrSet returns the value of <key>,
+// or sets <key>-<value> pair and returns <value> if <key> does not exist in the cache.
+func (t *Typed[T]) GetOrSet(ctx context.Context, key string, value T, duration time.Duration, tag ...string) (T, error) {
+	v, err := t.cache.GetOrSetE(ctx, key, value, duration, tag...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decodeVar[T](v)
+}
+
+// GetOrSetFunc returns the value of <key>, or sets <key> with result of function <f>
+// and returns its result if <key> does not exist in the cache.
+// The error of <f> is returned as it is and nothing is cached in that case.
+func (t *Typed[T]) GetOrSetFunc(ctx context.Context, key string, f func(ctx context.Context) (T, error), duration time.Duration, tag ...string) (T, error) {
+	v, err := t.cache.GetOrSetFuncE(ctx, key, t.wrapFunc(f), duration, tag...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decodeVar[T](v)
+}
+
+// GetOrSetFuncLock works like GetOrSetFunc, and only one process in the cluster calls <f>
+// for the redis cache.
+func (t *Typed[T]) GetOrSetFuncLock(ctx context.Context, key string, f func(ctx context.Context) (T, error), duration time.Duration, tag ...string) (T, error) {
+	v, err := t.cache.GetOrSetFuncLockE(ctx, key, t.wrapFunc(f), duration, tag...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decodeVar[T](v)
+}
+
+// Contains returns true if <key> exists in the cache, or else returns false.
+func (t *Typed[T]) Contains(ctx context.Context, key string) bool {
+	return t.cache.Contains(ctx, key)
+}
+
+// Remove deletes the <key> in the cache.
+func (t *Typed[T]) Remove(ctx context.Context, key string) {
+	t.cache.Remove(ctx, key)
+}
+
+func (t *Typed[T]) wrapFunc(f func(ctx context.Context) (T, error)) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		value, err := f(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// decodeVar converts the value of <v> to <T>.
+// The memory adapter keeps the original value, the redis adapter returns json strings and
+// the dist adapter returns raw bytes. The strings and bytes are decoded the same way for
+// all adapters: they are returned as they are for string, []byte and interface{}, and
+// decoded as json for the other types. The other values are converted by gconv.
+func decodeVar[T any](v *gvar.Var) (value T, err error) {
+	if v.IsNil() {
+		return
+	}
+	var raw []byte
+	switch r := v.Val().(type) {
+	case string:
+		raw = []byte(r)
+	case []byte:
+		raw = r
+	default:
+		if x, ok := v.Val().(T); ok {
+			return x, nil
+		}
+	}
+	if raw != nil {
+		// 字符串按原样返回，不按json去掉引号，与内存缓存保存的值一致
+		switch p := any(&value).(type) {
+		case *string:
+			*p = string(raw)
+			return
+		case *[]byte:
+			*p = raw
+			return
+		case *interface{}:
+			*p = string(raw)
+			return
+		}
+		if json.Valid(raw) {
+			if json.Unmarshal(raw, &value) == nil {
+				return
+			}
+			value = *new(T)
+		}
+	}
+	if err = v.Scan(&value); err != nil {
+		err = decodeError(gerror.Wrapf(err, `decode cache value to %T failed`, value))
+	}
+	return
+}
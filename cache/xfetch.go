@@ -0,0 +1,67 @@
+/*
+* @desc:提前过期(XFetch)及过期时间随机浮动，避免同时写入的键同时过期
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 20:30
+ */
+
+package cache
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// SetEarlyExpiration enables the probabilistic early expiration of the values written by
+// GetOrSetFunc and GetOrSetFuncLock, it should be called before the cache is used.
+// It is disabled if <beta> <= 0.
+//
+// Following the XFetch algorithm, the duration of the loader is recorded with the value,
+// and each read recomputes the value before it expires with the probability of
+// now - cost * <beta> * ln(rand()) >= expiry, so the more expensive the loader is and the
+// closer the value is to its expiry, the more likely it is recomputed. The recommended
+// <beta> is 1, the larger one favors the earlier recomputation.
+func (c *GfCache) SetEarlyExpiration(beta float64) *GfCache {
+	if c.l2 != nil {
+		c.l2.SetEarlyExpiration(beta)
+		return c
+	}
+	c.earlyBeta = beta
+	return c
+}
+
+// SetTTLJitter sets the jitter of the durations of Set, SetIfNotExist, GetOrSet and the
+// GetOrSetFunc family, it should be called before the cache is used. Each duration > 0 is
+// scaled by a random factor in [1 - <jitter>, 1 + <jitter>], so that the keys written in
+// the same batch do not expire at the same time. The <jitter> is in the range of [0, 1],
+// it is disabled if <jitter> is 0. The counters of IncrBy, the hashes and the values of
+// CompareAndSet always use the exact durations, as they often keep fixed windows.
+func (c *GfCache) SetTTLJitter(jitter float64) *GfCache {
+	if c.l2 != nil {
+		c.l2.SetTTLJitter(jitter)
+		return c
+	}
+	c.ttlJitter = min(max(jitter, 0), 1)
+	return c
+}
+
+// jitter returns <duration> scaled by the random factor of the TTL jitter.
+func (c *GfCache) jitter(duration time.Duration) time.Duration {
+	if c.ttlJitter <= 0 || duration <= 0 {
+		return duration
+	}
+	factor := 1 + c.ttlJitter*(2*rand.Float64()-1)
+	// 不能为0，否则变为永不过期
+	return max(time.Duration(float64(duration)*factor), time.Millisecond)
+}
+
+// recomputeEarly reports whether the unexpired entry <e> should be recomputed at <now>.
+func (c *GfCache) recomputeEarly(e *entry, now int64) bool {
+	if c.earlyBeta <= 0 || e.ExpireAt == 0 || e.Cost <= 0 {
+		return false
+	}
+	// 1-rand 的取值范围为(0, 1]，避免ln(0)
+	gap := float64(e.Cost) * c.earlyBeta * -math.Log(1-rand.Float64())
+	return float64(now)+gap >= float64(e.ExpireAt)
+}
@@ -0,0 +1,296 @@
+/*
+* @desc:缓存完整http响应的ghttp中间件
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 05:00
+ */
+
+// Package httpcache provides the ghttp middleware caching the full responses, including
+// the status, headers and body, in a cache.
+package httpcache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+// 响应缓存的键前缀，属于缓存的内部键
+const keyPrefix = "__httpcache:"
+
+const (
+	// HeaderCache is the response header reporting whether the response is served from
+	// the cache, its value is HIT or MISS.
+	HeaderCache = "X-Cache"
+	// DefaultTTL is the TTL of the cached responses if Options.TTL is not set.
+	DefaultTTL = time.Minute
+)
+
+// 不缓存的响应头
+var skippedHeaders = []string{"Age", "Date", HeaderCache}
+
+// Options is the options of the middleware.
+type Options struct {
+	// TTL is the TTL of the cached responses, it is overridden by the s-maxage or max-age
+	// of the Cache-Control header of the response. It is DefaultTTL if not set.
+	TTL time.Duration
+	// Tags are the tags of the cached responses, so that the responses of the routes can
+	// be invalidated by RemoveByTag after the data changes.
+	Tags []string
+	// Methods are the cached request methods, it is GET and HEAD if not set.
+	Methods []string
+	// Statuses are the cached response statuses, it is 200 if not set.
+	Statuses []int
+	// Headers are the request headers varying the responses, such as Accept-Language.
+	Headers []string
+	// IgnoreQuery ignores the query string in the key, if the responses of a route do
+	// not depend on it.
+	IgnoreQuery bool
+	// UserFunc returns the user ID of the request, the responses are cached per user if
+	// it is set. The private responses are cached only if it is set. If it is not set, the
+	// requests with the Authorization or Cookie header only share the responses which are
+	// explicitly public or s-maxage, as the others may depend on the user.
+	UserFunc func(r *ghttp.Request) string
+	// KeyFunc returns the key of the request, which overrides the default key derived from
+	// the method, path, query, Headers and UserFunc. The request is not cached if it returns
+	// an empty string.
+	KeyFunc func(r *ghttp.Request) string
+}
+
+// entry 缓存的响应
+type entry struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	Time   int64       `json:"time"`   // 缓存的时间，unix秒
+	Shared bool        `json:"shared"` // 响应明确声明为public或s-maxage，可以返回给带凭证的请求
+}
+
+// Middleware returns the middleware caching the responses in <c>. It should be bound
+// before the middleware writing the handler response, such as ghttp.MiddlewareHandlerResponse,
+// so that it caches the written body:
+//
+//	group.Middleware(httpcache.Middleware(c, httpcache.Options{Tags: []string{"dict"}}), ghttp.MiddlewareHandlerResponse)
+//
+// The cached responses are served with the Age header and the ETag header, which is
+// generated from the body if the handler does not set it, and the requests with a matching
+// If-None-Match header are replied with 304. The requests with Cache-Control no-store are
+// not cached, and the ones with no-cache or max-age=0 refresh the cached responses. The
+// responses with an error, Set-Cookie or Cache-Control no-store, no-cache, max-age=0 are
+// not cached. Without Options.UserFunc, the requests with the Authorization or Cookie header
+// are only served and cached with the responses marked as Cache-Control public or s-maxage.
+func Middleware(c cache.IGCacheE, opts Options) ghttp.HandlerFunc {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodGet, http.MethodHead}
+	}
+	if len(opts.Statuses) == 0 {
+		opts.Statuses = []int{http.StatusOK}
+	}
+	return func(r *ghttp.Request) {
+		directives := cacheControl(r.Header.Get("Cache-Control"))
+		if !slices.Contains(opts.Methods, r.Method) || directives.has("no-store") {
+			r.Middleware.Next()
+			return
+		}
+		key := opts.key(r)
+		if key == "" {
+			r.Middleware.Next()
+			return
+		}
+		var (
+			ctx          = r.Context()
+			credentialed = opts.credentialed(r)
+		)
+		if !directives.has("no-cache") && directives["max-age"] != "0" {
+			if e := getEntry(ctx, c, key); e != nil && (e.Shared || !credentialed) {
+				e.write(r)
+				return
+			}
+		}
+		r.Middleware.Next()
+		e, ttl := opts.entry(r, credentialed)
+		if e == nil {
+			return
+		}
+		data, err := json.Marshal(e)
+		if err == nil {
+			err = c.SetE(ctx, key, string(data), ttl, opts.Tags...)
+		}
+		if err != nil {
+			g.Log().Warning(ctx, err)
+		}
+		r.Response.Header().Set(HeaderCache, "MISS")
+		if etagMatch(r.Header.Get("If-None-Match"), e.Header.Get("ETag")) {
+			r.Response.ClearBuffer()
+			r.Response.WriteHeader(http.StatusNotModified)
+		}
+	}
+}
+
+// key 返回请求的缓存键
+func (opts *Options) key(r *ghttp.Request) string {
+	var key string
+	if opts.KeyFunc != nil {
+		key = opts.KeyFunc(r)
+		if key == "" {
+			return ""
+		}
+	} else {
+		var b strings.Builder
+		// HEAD请求和GET请求共享响应
+		method := r.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+		b.WriteString(method + " " + r.URL.Path)
+		if !opts.IgnoreQuery {
+			// Encode按参数名排序，参数顺序不同的请求共享响应
+			b.WriteString("?" + r.URL.Query().Encode())
+		}
+		for _, name := range opts.Headers {
+			b.WriteString("\n" + http.CanonicalHeaderKey(name) + ":" + strings.Join(r.Header.Values(name), ","))
+		}
+		if opts.UserFunc != nil {
+			b.WriteString("\nuser:" + opts.UserFunc(r))
+		}
+		key = b.String()
+	}
+	sum := sha1.Sum([]byte(key))
+	return keyPrefix + hex.EncodeToString(sum[:])
+}
+
+// credentialed 请求带有凭证且不按用户缓存时，响应可能因用户而不同
+func (opts *Options) credentialed(r *ghttp.Request) bool {
+	return opts.UserFunc == nil && (r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "")
+}
+
+// entry 返回需要缓存的响应及其缓存时间，不可缓存时返回nil
+func (opts *Options) entry(r *ghttp.Request, credentialed bool) (*entry, time.Duration) {
+	status := r.Response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := r.Response.Header()
+	if r.GetError() != nil || !slices.Contains(opts.Statuses, status) || header.Get("Set-Cookie") != "" {
+		return nil, 0
+	}
+	directives := cacheControl(header.Get("Cache-Control"))
+	if directives.has("no-store") || directives.has("no-cache") ||
+		(directives.has("private") && opts.UserFunc == nil) {
+		return nil, 0
+	}
+	shared := directives.has("public") || directives.has("s-maxage")
+	if credentialed && !shared {
+		return nil, 0
+	}
+	ttl := opts.TTL
+	maxAge, ok := directives["s-maxage"]
+	if !ok {
+		maxAge, ok = directives["max-age"]
+	}
+	if ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil || seconds <= 0 {
+			return nil, 0
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+	body := r.Response.Buffer()
+	if header.Get("ETag") == "" {
+		sum := sha1.Sum(body)
+		header.Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	}
+	e := &entry{
+		Status: status,
+		Header: header.Clone(),
+		Body:   body,
+		Time:   time.Now().Unix(),
+		Shared: shared,
+	}
+	for _, name := range skippedHeaders {
+		e.Header.Del(name)
+	}
+	return e, ttl
+}
+
+// getEntry 读取缓存的响应，不存在或读取失败时返回nil
+func getEntry(ctx context.Context, c cache.IGCacheE, key string) *entry {
+	v, err := c.GetE(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrNotFound) {
+			g.Log().Warning(ctx, err)
+		}
+		return nil
+	}
+	e := new(entry)
+	if err = json.Unmarshal(v.Bytes(), e); err != nil {
+		g.Log().Warning(ctx, err)
+		return nil
+	}
+	return e
+}
+
+// write 输出缓存的响应
+func (e *entry) write(r *ghttp.Request) {
+	header := r.Response.Header()
+	for name, values := range e.Header {
+		header[name] = values
+	}
+	header.Set("Age", strconv.FormatInt(max(time.Now().Unix()-e.Time, 0), 10))
+	header.Set(HeaderCache, "HIT")
+	if etagMatch(r.Header.Get("If-None-Match"), e.Header.Get("ETag")) {
+		r.Response.WriteHeader(http.StatusNotModified)
+		return
+	}
+	r.Response.WriteHeader(e.Status)
+	r.Response.Write(e.Body)
+}
+
+// directives Cache-Control头的指令
+type directives map[string]string
+
+// cacheControl 解析Cache-Control头
+func cacheControl(value string) directives {
+	d := directives{}
+	for _, part := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			d[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return d
+}
+
+func (d directives) has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
+
+// etagMatch 判断If-None-Match是否匹配<etag>，使用弱比较
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+/*
+* @desc:redis lua脚本
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 11:40
+ */
+
+// Package redisscript provides the lua scripts executed by EVALSHA.
+package redisscript
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/database/gredis"
+)
+
+// Script is a lua script executed by EVALSHA, it falls back to EVAL
+// if the script is not cached by the redis server yet.
+type Script struct {
+	src string
+	sha string
+}
+
+// New creates a lua script of <src>.
+func New(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{
+		src: src,
+		sha: hex.EncodeToString(sum[:]),
+	}
+}
+
+// Run executes the script with <keys> and <args>.
+func (s *Script) Run(ctx context.Context, redis *gredis.Redis, keys []string, args ...interface{}) (*gvar.Var, error) {
+	v, err := redis.EvalSha(ctx, s.sha, int64(len(keys)), keys, args)
+	if err != nil && strings.Contains(err.Error(), "NOSCRIPT") {
+		v, err = redis.Eval(ctx, s.src, int64(len(keys)), keys, args)
+	}
+	return v, err
+}
@@ -0,0 +1,78 @@
+/*
+* @desc:合并同一个键的并发调用
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 18:30
+ */
+
+// Package singleflight provides the duplicate call suppression per key.
+package singleflight
+
+import (
+	"fmt"
+	"sync"
+)
+
+// call 正在执行的调用
+type call struct {
+	wg    sync.WaitGroup
+	val   interface{}
+	err   error
+	panic interface{}
+	dups  int
+}
+
+// Group deduplicates the concurrent calls of the same key.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do executes and returns the results of <fn>, making sure that only one execution is in
+// flight for <key> at a time. The duplicate callers wait for the original one and receive
+// the same results, the returned <shared> reports whether the results are given to
+// multiple callers.
+//
+// If <fn> panics, the original caller panics again and the others receive an error.
+func (g *Group) Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		if c.panic != nil {
+			return nil, fmt.Errorf("singleflight: the call of key %q panicked: %v", key, c.panic), true
+		}
+		return c.val, c.err, true
+	}
+	c := new(call)
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	g.doCall(c, key, fn)
+	if c.panic != nil {
+		panic(c.panic)
+	}
+	g.mu.Lock()
+	shared = c.dups > 0
+	g.mu.Unlock()
+	return c.val, c.err, shared
+}
+
+// doCall 执行调用并唤醒等待者，panic时同样释放
+func (g *Group) doCall(c *call, key string, fn func() (interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.panic = r
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.val, c.err = fn()
+}
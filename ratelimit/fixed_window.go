@@ -0,0 +1,101 @@
+/*
+* @desc:固定窗口限流
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 04:00
+ */
+
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tiger1103/gfast-cache/cache"
+	"github.com/tiger1103/gfast-cache/internal/redisscript"
+)
+
+// 状态为"窗口开始时间:请求数"
+// ARGV[1] 请求数量, ARGV[2] 配额, ARGV[3] 窗口毫秒数
+var fixedWindowScript = redisscript.New(luaNow + `
+local n, limit, window = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local start = now - now % window
+local count = 0
+local s = redis.call('GET', KEYS[1])
+if s then
+	local a, b = string.match(s, '^([^:]+):([^:]+)$')
+	if tonumber(a) == start then
+		count = tonumber(b)
+	end
+end
+local allowed = 0
+if count + n <= limit then
+	count = count + n
+	allowed = 1
+	redis.call('SET', KEYS[1], start .. ':' .. count, 'PX', start + window - now + 1000)
+end
+return {allowed, tostring(now), start .. ':' .. count}
+`)
+
+// fixedWindow 固定窗口，每个窗口内最多允许limit个请求
+type fixedWindow struct {
+	limit  int64
+	window int64 // 窗口毫秒数
+}
+
+// NewFixedWindow creates a limiter allowing at most <limit> requests in each <window>,
+// the windows are aligned to the unix epoch. It is the cheapest one, but it allows up to
+// 2 * <limit> requests around the boundary of two windows. It panics if <limit> or <window>
+// is not positive.
+func NewFixedWindow(c cache.IGCacheE, limit int64, window time.Duration) *Limiter {
+	checkWindow(limit, window)
+	return &Limiter{
+		c:   c,
+		alg: &fixedWindow{limit: limit, window: max(window.Milliseconds(), 1)},
+	}
+}
+
+func (a *fixedWindow) name() string {
+	return "fw"
+}
+
+func (a *fixedWindow) script() *redisscript.Script {
+	return fixedWindowScript
+}
+
+func (a *fixedWindow) args() []interface{} {
+	return []interface{}{a.limit, a.window}
+}
+
+func (a *fixedWindow) take(state string, now, n int64) (string, time.Duration, bool) {
+	var (
+		start = now - now%a.window
+		count int64
+	)
+	if s := parseState(state, 2); s != nil && int64(s[0]) == start {
+		count = int64(s[1])
+	}
+	allowed := count+n <= a.limit
+	if allowed {
+		count += n
+	}
+	ttl := time.Duration(start+a.window-now)*time.Millisecond + stateTTLMargin
+	return fmt.Sprintf("%d:%d", start, count), ttl, allowed
+}
+
+func (a *fixedWindow) result(state string, now, n int64, allowed bool) *Result {
+	var start, count int64
+	if s := parseState(state, 2); s != nil {
+		start, count = int64(s[0]), int64(s[1])
+	}
+	r := &Result{
+		Allowed:   allowed,
+		Limit:     a.limit,
+		Remaining: max(a.limit-count, 0),
+		ResetAt:   msTime(start + a.window),
+	}
+	if !allowed && n <= a.limit {
+		r.RetryAfter = time.Duration(start+a.window-now) * time.Millisecond
+	}
+	return r
+}
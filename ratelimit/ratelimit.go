@@ -0,0 +1,164 @@
+/*
+* @desc:基于缓存后端的限流器，redis通过lua脚本原子更新，其它缓存通过比较并交换更新
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 03:40
+ */
+
+// Package ratelimit provides the fixed-window, sliding-window and token-bucket rate
+// limiters sharing the backend of a cache.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gredis"
+	"github.com/tiger1103/gfast-cache/cache"
+	"github.com/tiger1103/gfast-cache/internal/redisscript"
+)
+
+// 限流状态的键前缀，属于缓存的内部键
+const keyPrefix = "__ratelimit:"
+
+// 状态中记录了窗口或时间，过期时间只用于清理，多保留一秒以兼容磁盘缓存秒级的过期时间
+const stateTTLMargin = time.Second
+
+// 非redis缓存比较并交换失败时的最大重试次数
+const maxCASRetries = 100
+
+// ErrContention is returned by AllowN when the state of the key is changed by the others in
+// every attempt, which happens only under heavy contention on the memory or dist cache.
+var ErrContention = errors.New("ratelimit: too many concurrent updates")
+
+// 读取当前毫秒时间，多个节点使用redis的时间
+const luaNow = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+`
+
+// checkWindow 校验窗口限流器的参数，不合法时panic
+func checkWindow(limit int64, window time.Duration) {
+	if limit <= 0 {
+		panic(fmt.Sprintf("ratelimit: limit must be positive, got %d", limit))
+	}
+	if window <= 0 {
+		panic(fmt.Sprintf("ratelimit: window must be positive, got %s", window))
+	}
+}
+
+// Result is the result of a rate limit check.
+type Result struct {
+	Allowed    bool          // 是否允许
+	Limit      int64         // 窗口内的配额或令牌桶的容量
+	Remaining  int64         // 剩余配额
+	ResetAt    time.Time     // 配额完全恢复的时间
+	RetryAfter time.Duration // 被拒绝时需要等待的时间，请求数量超过配额时为0
+}
+
+// algorithm 限流算法，redis脚本和take实现相同的状态转换
+type algorithm interface {
+	// name 算法名，用于状态的键名
+	name() string
+	// script 原子更新状态的redis脚本，KEYS[1]为状态，ARGV[1]为请求数量，ARGV[2...]为args，
+	// 返回{是否允许, 当前毫秒时间, 新状态}
+	script() *redisscript.Script
+	args() []interface{}
+	// take 根据<now>时的状态<state>处理<n>个请求，返回新状态及其过期时间，未允许时状态不需要写入
+	take(state string, now, n int64) (next string, ttl time.Duration, allowed bool)
+	// result 根据处理后的状态返回结果
+	result(state string, now, n int64, allowed bool) *Result
+}
+
+// Limiter is a rate limiter whose state is saved in the backend of a cache, so that the
+// limiters of the processes sharing a redis or dist cache share the same quota. The state
+// is updated atomically by a lua script for the redis cache, and by CompareAndSetE for the
+// other caches.
+type Limiter struct {
+	c   cache.IGCacheE
+	alg algorithm
+}
+
+// Allow reports whether one request of <key> is allowed, see AllowN.
+func (l *Limiter) Allow(ctx context.Context, key string) (*Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN reports whether <n> requests of <key> are allowed at once, the quota is consumed
+// only if they are allowed. It returns ErrContention if the state keeps being changed by the
+// others while it is updated.
+func (l *Limiter) AllowN(ctx context.Context, key string, n int64) (*Result, error) {
+	stateKey := keyPrefix + l.alg.name() + ":" + key
+	if redis, prefix := redisOf(l.c); redis != nil {
+		args := append([]interface{}{n}, l.alg.args()...)
+		v, err := l.alg.script().Run(ctx, redis, []string{prefix + stateKey}, args...)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", cache.ErrBackendUnavailable, err)
+		}
+		reply := v.Strings()
+		if len(reply) != 3 {
+			return nil, fmt.Errorf("%w: invalid rate limit reply %v", cache.ErrDecode, reply)
+		}
+		now, _ := strconv.ParseInt(reply[1], 10, 64)
+		return l.alg.result(reply[2], now, n, reply[0] == "1"), nil
+	}
+	for i := 0; i < maxCASRetries; i++ {
+		v, version, err := l.c.GetWithVersionE(ctx, stateKey)
+		if err != nil && !errors.Is(err, cache.ErrNotFound) {
+			return nil, err
+		}
+		var state string
+		if v != nil {
+			state = v.String()
+		}
+		now := time.Now().UnixMilli()
+		next, ttl, allowed := l.alg.take(state, now, n)
+		if allowed {
+			ok, err := l.c.CompareAndSetE(ctx, stateKey, version, next, ttl)
+			if err != nil {
+				return nil, err
+			}
+			// 状态已被其它请求修改，重新计算
+			if !ok {
+				continue
+			}
+		}
+		return l.alg.result(next, now, n, allowed), nil
+	}
+	return nil, ErrContention
+}
+
+// redisOf returns the redis client and the key prefix of <c> if it is backed by redis.
+func redisOf(c cache.IGCacheE) (*gredis.Redis, string) {
+	gc, ok := c.(*cache.GfCache)
+	if !ok {
+		return nil, ""
+	}
+	return gc.Redis(), gc.CachePrefix
+}
+
+// parseState 解析以冒号分隔的状态，格式不正确时返回nil
+func parseState(state string, size int) []float64 {
+	parts := strings.Split(state, ":")
+	if len(parts) != size {
+		return nil
+	}
+	values := make([]float64, size)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// msTime 毫秒时间戳转换为时间
+func msTime(ms int64) time.Time {
+	return time.UnixMilli(ms)
+}
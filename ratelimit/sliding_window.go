@@ -0,0 +1,131 @@
+/*
+* @desc:滑动窗口限流，按上一个窗口的请求数加权估算
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 04:10
+ */
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/tiger1103/gfast-cache/cache"
+	"github.com/tiger1103/gfast-cache/internal/redisscript"
+)
+
+// 状态为"当前窗口开始时间:上一个窗口的请求数:当前窗口的请求数"
+// ARGV[1] 请求数量, ARGV[2] 配额, ARGV[3] 窗口毫秒数
+var slidingWindowScript = redisscript.New(luaNow + `
+local n, limit, window = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local start = now - now % window
+local prev, cur = 0, 0
+local s = redis.call('GET', KEYS[1])
+if s then
+	local a, b, c = string.match(s, '^([^:]+):([^:]+):([^:]+)$')
+	a = tonumber(a)
+	if a == start then
+		prev, cur = tonumber(b), tonumber(c)
+	elseif a == start - window then
+		prev = tonumber(c)
+	end
+end
+local allowed = 0
+if prev * (window - (now - start)) / window + cur + n <= limit then
+	cur = cur + n
+	allowed = 1
+	redis.call('SET', KEYS[1], start .. ':' .. prev .. ':' .. cur, 'PX', start + 2 * window - now + 1000)
+end
+return {allowed, tostring(now), start .. ':' .. prev .. ':' .. cur}
+`)
+
+// slidingWindow 滑动窗口，过去一个窗口长度内的请求数按上一个窗口的请求数加权估算
+type slidingWindow struct {
+	limit  int64
+	window int64 // 窗口毫秒数
+}
+
+// NewSlidingWindow creates a limiter allowing at most <limit> requests in any <window>.
+// The requests in the past <window> are estimated by the count of the current window plus
+// the count of the previous window weighted by its overlap, so that it does not allow the
+// bursts around the window boundary like the fixed window. It panics if <limit> or <window>
+// is not positive.
+func NewSlidingWindow(c cache.IGCacheE, limit int64, window time.Duration) *Limiter {
+	checkWindow(limit, window)
+	return &Limiter{
+		c:   c,
+		alg: &slidingWindow{limit: limit, window: max(window.Milliseconds(), 1)},
+	}
+}
+
+func (a *slidingWindow) name() string {
+	return "sw"
+}
+
+func (a *slidingWindow) script() *redisscript.Script {
+	return slidingWindowScript
+}
+
+func (a *slidingWindow) args() []interface{} {
+	return []interface{}{a.limit, a.window}
+}
+
+// parse 解析状态，返回当前窗口的开始时间和上一个、当前窗口的请求数
+func (a *slidingWindow) parse(state string, now int64) (start, prev, cur int64) {
+	start = now - now%a.window
+	if s := parseState(state, 3); s != nil {
+		switch int64(s[0]) {
+		case start:
+			prev, cur = int64(s[1]), int64(s[2])
+		case start - a.window:
+			prev = int64(s[2])
+		}
+	}
+	return
+}
+
+// estimate 估算过去一个窗口内的请求数
+func (a *slidingWindow) estimate(start, prev, cur, now int64) float64 {
+	return float64(prev)*float64(a.window-(now-start))/float64(a.window) + float64(cur)
+}
+
+func (a *slidingWindow) take(state string, now, n int64) (string, time.Duration, bool) {
+	start, prev, cur := a.parse(state, now)
+	allowed := a.estimate(start, prev, cur, now)+float64(n) <= float64(a.limit)
+	if allowed {
+		cur += n
+	}
+	ttl := time.Duration(start+2*a.window-now)*time.Millisecond + stateTTLMargin
+	return fmt.Sprintf("%d:%d:%d", start, prev, cur), ttl, allowed
+}
+
+func (a *slidingWindow) result(state string, now, n int64, allowed bool) *Result {
+	start, prev, cur := a.parse(state, now)
+	r := &Result{
+		Allowed:   allowed,
+		Limit:     a.limit,
+		Remaining: max(int64(math.Floor(float64(a.limit)-a.estimate(start, prev, cur, now))), 0),
+		ResetAt:   msTime(now),
+	}
+	switch {
+	case cur > 0:
+		r.ResetAt = msTime(start + 2*a.window)
+	case prev > 0:
+		r.ResetAt = msTime(start + a.window)
+	}
+	if allowed || n > a.limit {
+		return r
+	}
+	var at int64
+	if cur+n <= a.limit {
+		// 上一个窗口的权重降低到足够时允许
+		at = start + int64(math.Ceil(float64(a.window)-float64(a.limit-cur-n)*float64(a.window)/float64(prev)))
+	} else {
+		// 下一个窗口中当前窗口的权重降低到足够时允许
+		at = start + a.window + int64(math.Ceil(float64(a.window)-float64(a.limit-n)*float64(a.window)/float64(cur)))
+	}
+	r.RetryAfter = max(time.Duration(at-now)*time.Millisecond, 0)
+	return r
+}
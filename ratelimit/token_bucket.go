@@ -0,0 +1,113 @@
+/*
+* @desc:令牌桶限流
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 04:20
+ */
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/tiger1103/gfast-cache/cache"
+	"github.com/tiger1103/gfast-cache/internal/redisscript"
+)
+
+// 状态为"令牌数:更新时间"
+// ARGV[1] 请求数量, ARGV[2] 每秒补充的令牌数, ARGV[3] 桶的容量
+var tokenBucketScript = redisscript.New(luaNow + `
+local n, rate, burst = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local tokens = burst
+local s = redis.call('GET', KEYS[1])
+if s then
+	local a, b = string.match(s, '^([^:]+):([^:]+)$')
+	if tonumber(a) and tonumber(b) then
+		tokens = math.min(burst, tonumber(a) + math.max(now - tonumber(b), 0) * rate / 1000)
+	end
+end
+local allowed = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+	redis.call('SET', KEYS[1], tokens .. ':' .. now, 'PX', math.ceil((burst - tokens) / rate * 1000) + 1000)
+end
+return {allowed, tostring(now), tokens .. ':' .. now}
+`)
+
+// tokenBucket 令牌桶，每秒补充rate个令牌，最多保存burst个
+type tokenBucket struct {
+	rate  float64
+	burst int64
+}
+
+// NewTokenBucket creates a limiter which refills <rate> tokens per second into a bucket
+// holding at most <burst> tokens, and each request takes a token. It allows the bursts up
+// to <burst> requests and <rate> requests per second on average. It panics if <rate> or
+// <burst> is not positive.
+func NewTokenBucket(c cache.IGCacheE, rate float64, burst int64) *Limiter {
+	// rate为0时等待时间为无穷大，!(rate > 0)同时排除NaN
+	if !(rate > 0) {
+		panic(fmt.Sprintf("ratelimit: rate must be positive, got %v", rate))
+	}
+	if burst <= 0 {
+		panic(fmt.Sprintf("ratelimit: burst must be positive, got %d", burst))
+	}
+	return &Limiter{
+		c:   c,
+		alg: &tokenBucket{rate: rate, burst: burst},
+	}
+}
+
+func (a *tokenBucket) name() string {
+	return "tb"
+}
+
+func (a *tokenBucket) script() *redisscript.Script {
+	return tokenBucketScript
+}
+
+func (a *tokenBucket) args() []interface{} {
+	return []interface{}{a.rate, a.burst}
+}
+
+// tokens 返回<now>时的令牌数
+func (a *tokenBucket) tokens(state string, now int64) float64 {
+	s := parseState(state, 2)
+	if s == nil {
+		return float64(a.burst)
+	}
+	return min(float64(a.burst), s[0]+max(float64(now)-s[1], 0)*a.rate/1000)
+}
+
+// fullIn 令牌补满需要的时间
+func (a *tokenBucket) fullIn(tokens float64) time.Duration {
+	return time.Duration(math.Ceil((float64(a.burst)-tokens)/a.rate*1000)) * time.Millisecond
+}
+
+func (a *tokenBucket) take(state string, now, n int64) (string, time.Duration, bool) {
+	tokens := a.tokens(state, now)
+	allowed := tokens >= float64(n)
+	if allowed {
+		tokens -= float64(n)
+	}
+	next := strconv.FormatFloat(tokens, 'f', -1, 64) + ":" + strconv.FormatInt(now, 10)
+	return next, a.fullIn(tokens) + stateTTLMargin, allowed
+}
+
+func (a *tokenBucket) result(state string, now, n int64, allowed bool) *Result {
+	tokens := a.tokens(state, now)
+	r := &Result{
+		Allowed:   allowed,
+		Limit:     a.burst,
+		Remaining: int64(math.Floor(tokens)),
+		ResetAt:   msTime(now).Add(a.fullIn(tokens)),
+	}
+	if !allowed && n <= a.burst {
+		r.RetryAfter = time.Duration(math.Ceil((float64(n)-tokens)/a.rate*1000)) * time.Millisecond
+	}
+	return r
+}
@@ -0,0 +1,117 @@
+/*
+* @desc:各后端过期语义差异测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 23:10
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/adapter"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+// redis的过期时间由服务端计算，使用FastForward验证写入的TTL
+func Test_RedisExpiry(t *testing.T) {
+	ctx := context.Background()
+	loadErr := errors.New("db timeout")
+	fail := func(ctx context.Context) (interface{}, error) {
+		return nil, loadErr
+	}
+	// 过期值在宽限期内保留，宽限期后由redis删除
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewRedis("redis_expiry_stale_", testRedisName).SetStaleIfError(time.Minute)
+		c.GetOrSetFunc(ctx, "order", func(ctx context.Context) (interface{}, error) {
+			return "v1", nil
+		}, 200*time.Millisecond)
+		ttl := redisServer.TTL("redis_expiry_stale_order")
+		t.Assert(ttl > time.Minute && ttl <= time.Minute+200*time.Millisecond, true)
+		time.Sleep(300 * time.Millisecond)
+		v, err := c.GetOrSetFuncE(ctx, "order", fail, 200*time.Millisecond)
+		t.Assert(v, "v1")
+		t.Assert(errors.Is(err, cache.ErrStale), true)
+		redisServer.FastForward(2 * time.Minute)
+		v, err = c.GetOrSetFuncE(ctx, "order", fail, 200*time.Millisecond)
+		t.AssertNil(v)
+		t.Assert(errors.Is(err, loadErr), true)
+		t.Assert(errors.Is(err, cache.ErrStale), false)
+	})
+	// 已知不存在的键按负缓存时间过期
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewRedis("redis_expiry_negative_", testRedisName).SetNegativeTTL(30 * time.Second)
+		var calls int32
+		load := func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return cache.NotFound, nil
+		}
+		c.GetOrSetFunc(ctx, "user:404", load, time.Hour)
+		c.GetOrSetFunc(ctx, "user:404", load, time.Hour)
+		t.Assert(atomic.LoadInt32(&calls), 1)
+		ttl := redisServer.TTL("redis_expiry_negative_user:404")
+		t.Assert(ttl > 0 && ttl <= 30*time.Second, true)
+		redisServer.FastForward(31 * time.Second)
+		c.GetOrSetFunc(ctx, "user:404", load, time.Hour)
+		t.Assert(atomic.LoadInt32(&calls), 2)
+		c.Clear(ctx)
+	})
+	// 未续期的锁过期后可以被其它持有者获取
+	gtest.C(t, func(t *gtest.T) {
+		locker := cache.NewRedis("redis_expiry_locker_", testRedisName).Locker()
+		lock, err := locker.TryLock(ctx, "job", 30*time.Second)
+		t.AssertNil(err)
+		_, err = locker.TryLock(ctx, "job", 30*time.Second)
+		t.Assert(errors.Is(err, cache.ErrLockHeld), true)
+		redisServer.FastForward(31 * time.Second)
+		next, err := locker.TryLock(ctx, "job", 30*time.Second)
+		t.AssertNil(err)
+		t.Assert(next.Token(), lock.Token()+1)
+		t.Assert(errors.Is(lock.Unlock(ctx), cache.ErrLockNotHeld), true)
+		t.AssertNil(next.Unlock(ctx))
+	})
+}
+
+// 磁盘缓存的过期时间精确到秒并向下取整，逻辑过期时间仍精确到毫秒
+func Test_DistExpiry(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewDist("dist_expiry_").SetStaleIfError(time.Minute)
+		c.Set(ctx, "a", "1", time.Second)
+		c.Set(ctx, "forever", "1", 0)
+		c.GetOrSetFunc(ctx, "order", func(ctx context.Context) (interface{}, error) {
+			return "v1", nil
+		}, 200*time.Millisecond)
+		// 向下取整最多提前一秒删除
+		expire, err := adapter.NewDist().GetExpire(ctx, "dist_expiry_a")
+		t.AssertNil(err)
+		t.Assert(expire > 0 && expire <= time.Second, true)
+		// 不过期的键返回极大的剩余时间
+		expire, err = adapter.NewDist().GetExpire(ctx, "dist_expiry_forever")
+		t.AssertNil(err)
+		t.Assert(expire > 100*365*24*time.Hour, true)
+
+		// 逻辑过期的条目按毫秒判断
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(c.Contains(ctx, "order"), false)
+		v, err := c.GetOrSetFuncE(ctx, "order", func(ctx context.Context) (interface{}, error) {
+			return nil, errors.New("db timeout")
+		}, 200*time.Millisecond)
+		t.Assert(v, "v1")
+		t.Assert(errors.Is(err, cache.ErrStale), true)
+
+		deadline := time.Now().Add(2 * time.Second)
+		for c.Contains(ctx, "a") && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Assert(c.Contains(ctx, "a"), false)
+		t.Assert(c.Get(ctx, "forever"), "1")
+		c.Clear(ctx)
+	})
+}
@@ -0,0 +1,81 @@
+/*
+* @desc:批量读写测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 22:50
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_GetSetMany(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("batch_")
+	caches["tiered"] = cache.NewTiered("batch_tiered_", cache.L1Options{TTL: time.Minute}, cache.NewRedis("batch_l2_", testRedisName))
+	caches["generation"] = cache.NewRedis("batch_gen_", testRedisName).SetGeneration(true)
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			data := make(map[string]interface{})
+			for i := 0; i < 5; i++ {
+				data[fmt.Sprintf("user:%d", i)] = fmt.Sprintf("name%d", i)
+			}
+			t.AssertNil(c.SetManyE(ctx, data, time.Hour, "user"))
+			values := c.GetMany(ctx, []string{"user:0", "user:4", "user:9"})
+			t.Assert(len(values), 2)
+			t.Assert(values["user:0"], "name0")
+			t.Assert(values["user:4"], "name4")
+			keys := c.KeysByTag(ctx, "user")
+			sort.Strings(keys)
+			t.Assert(keys, []string{"user:0", "user:1", "user:2", "user:3", "user:4"})
+
+			// 只加载缺失的键，且只调用一次
+			var requested [][]string
+			load := func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+				sort.Strings(keys)
+				requested = append(requested, keys)
+				result := make(map[string]interface{})
+				for _, key := range keys {
+					if key == "user:8" {
+						result[key] = cache.NotFound
+					} else {
+						result[key] = "loaded"
+					}
+				}
+				return result, nil
+			}
+			values, err := c.GetOrSetManyFuncE(ctx, []string{"user:1", "user:6", "user:7", "user:8", "user:6"}, load, time.Hour, "user")
+			t.AssertNil(err)
+			t.Assert(requested, [][]string{{"user:6", "user:7", "user:8"}})
+			t.Assert(len(values), 3)
+			t.Assert(values["user:1"], "name1")
+			t.Assert(values["user:6"], "loaded")
+			values = c.GetOrSetManyFunc(ctx, []string{"user:6", "user:7", "user:8"}, load, time.Hour, "user")
+			t.Assert(len(values), 2)
+			t.Assert(len(requested), 1)
+			_, err = c.GetE(ctx, "user:8")
+			t.Assert(errors.Is(err, cache.ErrAbsent), true)
+
+			// 加载失败时返回已缓存的值
+			values, err = c.GetOrSetManyFuncE(ctx, []string{"user:2", "user:10"}, func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+				return nil, errors.New("db timeout")
+			}, time.Hour)
+			t.AssertNE(err, nil)
+			t.Assert(len(values), 1)
+
+			c.RemoveByTag(ctx, "user")
+			t.Assert(len(c.GetMany(ctx, []string{"user:0", "user:6"})), 0)
+			c.Clear(ctx)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+* @desc:布隆过滤器测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 22:10
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_Bloom(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("bloom_") {
+		gtest.C(t, func(t *gtest.T) {
+			t.AssertNil(c.SetBloom(ctx, cache.BloomOptions{Expected: 1000, FalsePositiveRate: 0.001}))
+			var calls int32
+			load := func(ctx context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				return "user", nil
+			}
+			// 不在过滤器中的键不调用加载函数
+			v, err := c.GetOrSetFuncE(ctx, "user:1", load, time.Hour)
+			t.AssertNil(v)
+			t.Assert(errors.Is(err, cache.ErrAbsent), true)
+			t.Assert(atomic.LoadInt32(&calls), 0)
+
+			t.AssertNil(c.AddBloom(ctx, "user:1", "user:2"))
+			t.Assert(c.GetOrSetFunc(ctx, "user:1", load, time.Hour), "user")
+			t.Assert(c.GetOrSetFuncSWR(ctx, "user:2", load, time.Hour, time.Hour), "user")
+			t.Assert(atomic.LoadInt32(&calls), 2)
+			t.AssertNil(c.GetOrSetFuncSWR(ctx, "user:3", load, time.Hour, time.Hour))
+			t.Assert(atomic.LoadInt32(&calls), 2)
+
+			// 重建后只包含新的成员，且不受Clear影响
+			members := make([]string, 0, 100)
+			for i := 100; i < 200; i++ {
+				members = append(members, fmt.Sprintf("user:%d", i))
+			}
+			t.AssertNil(c.RebuildBloom(ctx, members))
+			c.Clear(ctx)
+			for _, member := range members {
+				ok, err := c.BloomMayContain(ctx, member)
+				t.AssertNil(err)
+				t.Assert(ok, true)
+			}
+			ok, err := c.BloomMayContain(ctx, "user:1")
+			t.AssertNil(err)
+			t.Assert(ok, false)
+			t.Assert(c.GetOrSetFunc(ctx, "user:100", load, time.Hour), "user")
+			t.AssertNil(c.GetOrSetFunc(ctx, "user:1", load, time.Hour))
+			t.Assert(atomic.LoadInt32(&calls), 3)
+			c.Clear(ctx)
+		})
+	}
+}
+
+func Test_BloomPersist(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		opts := cache.BloomOptions{Expected: 1000}
+		c := cache.NewDist("bloom_persist_")
+		t.AssertNil(c.SetBloom(ctx, opts))
+		t.AssertNil(c.AddBloom(ctx, "user:1"))
+		t.AssertNil(c.SaveBloom(ctx))
+		// 重新加载持久化的过滤器
+		t.AssertNil(c.SetBloom(ctx, opts))
+		ok, err := c.BloomMayContain(ctx, "user:1")
+		t.AssertNil(err)
+		t.Assert(ok, true)
+		ok, err = c.BloomMayContain(ctx, "user:2")
+		t.AssertNil(err)
+		t.Assert(ok, false)
+
+		// redis的过滤器直接保存在位图中
+		r := cache.NewRedis("bloom_persist_", testRedisName)
+		t.AssertNil(r.SetBloom(ctx, opts))
+		t.AssertNil(r.AddBloom(ctx, "user:1"))
+		var persisted []string
+		for _, key := range redisServer.Keys() {
+			if strings.HasPrefix(key, "__gfcache_bloom:") && strings.HasSuffix(key, ":bloom_persist_") {
+				persisted = append(persisted, key)
+			}
+		}
+		t.Assert(len(persisted), 1)
+	})
+}
+
+func Test_BloomOptionsChanged(t *testing.T) {
+	ctx := context.Background()
+	caches := map[string]*cache.GfCache{
+		"redis": cache.NewRedis("bloom_opts_", testRedisName),
+		"dist":  cache.NewDist("bloom_opts_"),
+	}
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			// 位图较小，添加足够多的成员后所有位都被设置
+			small := cache.BloomOptions{Expected: 10}
+			t.AssertNil(c.SetBloom(ctx, small))
+			members := make([]string, 0, 1000)
+			for i := 0; i < 1000; i++ {
+				members = append(members, fmt.Sprintf("user:%d", i))
+			}
+			t.AssertNil(c.AddBloom(ctx, members...))
+			t.AssertNil(c.SaveBloom(ctx))
+			ok, err := c.BloomMayContain(ctx, "user:unknown")
+			t.AssertNil(err)
+			t.Assert(ok, true)
+
+			// 不同参数的过滤器不使用已保存的位图
+			t.AssertNil(c.SetBloom(ctx, cache.BloomOptions{Expected: 1}))
+			ok, err = c.BloomMayContain(ctx, "user:unknown")
+			t.AssertNil(err)
+			t.Assert(ok, false)
+
+			// 恢复原参数后重新加载
+			t.AssertNil(c.SetBloom(ctx, small))
+			ok, err = c.BloomMayContain(ctx, "user:1")
+			t.AssertNil(err)
+			t.Assert(ok, true)
+		})
+	}
+}
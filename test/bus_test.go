@@ -0,0 +1,184 @@
+/*
+* @desc:失效消息总线测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 18:10
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_Bus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := cache.NewMemoryBus()
+	// 两个前缀模拟两个节点
+	nodes := map[string][2]*cache.GfCache{
+		"memory": {cache.New("bus_node1_"), cache.New("bus_node2_")},
+		"dist":   {cache.NewDist("bus_node1_"), cache.NewDist("bus_node2_")},
+		"tiered": {
+			cache.NewTiered("bus_node1_", cache.L1Options{TTL: time.Minute}, cache.NewRedis("bus_", testRedisName)),
+			cache.NewTiered("bus_node2_", cache.L1Options{TTL: time.Minute}, cache.NewRedis("bus_", testRedisName)),
+		},
+	}
+	for name, n := range nodes {
+		node1, node2 := n[0], n[1]
+		gtest.C(t, func(t *gtest.T) {
+			for i, c := range n {
+				t.AssertNil(c.SetBus(ctx, cache.BusOptions{
+					Bus:     bus,
+					NodeId:  []string{"node1", "node2"}[i],
+					Channel: "bus_" + name,
+				}))
+			}
+			// 二级缓存的L2为共享的redis，其它缓存的本地数据被删除，都不会读取到旧值
+			node1.Set(ctx, "a", 1, 0, "t")
+			t.Assert(node1.Get(ctx, "a").Int(), 1)
+			node2.Set(ctx, "a", 2, 0, "t")
+			t.AssertNE(node1.Get(ctx, "a").Int(), 1)
+			t.Assert(node2.Get(ctx, "a").Int(), 2)
+
+			node1.Set(ctx, "b", 3, 0, "org:1/dept:3")
+			node2.Set(ctx, "b", 3, 0, "org:1/dept:3")
+			node2.Get(ctx, "a")
+			node2.Get(ctx, "b")
+			node1.RemoveByTag(ctx, "t")
+			t.Assert(node2.Contains(ctx, "a"), false)
+			node1.RemoveByTagPattern(ctx, "org:1/*")
+			t.Assert(node2.Contains(ctx, "b"), false)
+
+			node2.Set(ctx, "c", 4, 0)
+			t.Assert(node2.Get(ctx, "c").Int(), 4)
+			node1.Remove(ctx, "c")
+			t.Assert(node2.Contains(ctx, "c"), false)
+
+			// 重新连接后清空本地缓存
+			node1.Set(ctx, "d", 4, 0)
+			t.Assert(node1.Contains(ctx, "d"), true)
+			bus.Resync()
+			if name != "tiered" {
+				t.Assert(node1.Contains(ctx, "d"), false)
+			}
+			node1.Clear(ctx)
+			node2.Clear(ctx)
+		})
+	}
+}
+
+func Test_RedisBus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gtest.C(t, func(t *gtest.T) {
+		node1, node2 := cache.New("redis_bus_node1_"), cache.New("redis_bus_node2_")
+		for _, c := range []*cache.GfCache{node1, node2} {
+			t.AssertNil(c.SetBus(ctx, cache.BusOptions{
+				Bus:     cache.NewRedisBus(testRedisName),
+				Channel: "redis_bus",
+			}))
+		}
+		node1.Set(ctx, "a", 1, 0)
+		node2.Set(ctx, "a", 1, 0)
+		time.Sleep(100 * time.Millisecond)
+		t.Assert(node1.Contains(ctx, "a"), false)
+		t.Assert(node2.Contains(ctx, "a"), true)
+		node1.Set(ctx, "a", 1, 0)
+		time.Sleep(100 * time.Millisecond)
+		t.Assert(node2.Contains(ctx, "a"), false)
+	})
+}
+
+// failingBus 发布总是失败的消息总线
+type failingBus struct{}
+
+func (failingBus) Publish(ctx context.Context, channel string, message []byte) error {
+	return errors.New("connection refused")
+}
+
+func (failingBus) Subscribe(ctx context.Context, channel string, onMessage func(message []byte), onResync func()) error {
+	return nil
+}
+
+func Test_BusPublishError(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.New("bus_publish_error_")
+		t.AssertNil(c.SetBus(ctx, cache.BusOptions{Bus: failingBus{}}))
+		// 写入成功，发布失败单独返回
+		err := c.SetE(ctx, "a", 1, 0)
+		t.Assert(errors.Is(err, cache.ErrPublish), true)
+		t.Assert(errors.Is(err, cache.ErrBackendUnavailable), false)
+		t.Assert(c.Get(ctx, "a").Int(), 1)
+		_, err = c.RemoveE(ctx, "a")
+		t.Assert(errors.Is(err, cache.ErrPublish), true)
+		t.Assert(c.Contains(ctx, "a"), false)
+	})
+}
+
+// 计数器和比较并交换的状态由各节点独立保存，只删除二级缓存的L1
+func Test_BusCounter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := cache.NewMemoryBus()
+	nodes := map[string][2]*cache.GfCache{
+		"memory": {cache.New("bus_counter_node1_"), cache.New("bus_counter_node2_")},
+		"dist":   {cache.NewDist("bus_counter_node1_"), cache.NewDist("bus_counter_node2_")},
+		"tiered": {
+			cache.NewTiered("bus_counter_node1_", cache.L1Options{TTL: time.Minute}, cache.NewRedis("bus_counter_", testRedisName)),
+			cache.NewTiered("bus_counter_node2_", cache.L1Options{TTL: time.Minute}, cache.NewRedis("bus_counter_", testRedisName)),
+		},
+	}
+	for name, n := range nodes {
+		node1, node2 := n[0], n[1]
+		gtest.C(t, func(t *gtest.T) {
+			for i, c := range n {
+				t.AssertNil(c.SetBus(ctx, cache.BusOptions{
+					Bus:     bus,
+					NodeId:  []string{"node1", "node2"}[i],
+					Channel: "bus_counter_" + name,
+				}))
+			}
+			node1.Incr(ctx, "hits", time.Hour)
+			node2.Incr(ctx, "hits", time.Hour)
+			_, version := node2.GetWithVersion(ctx, "state")
+			t.Assert(node2.CompareAndSet(ctx, "state", version, "s2", time.Hour), true)
+			_, version = node1.GetWithVersion(ctx, "state")
+			t.Assert(node1.CompareAndSet(ctx, "state", version, "s1", time.Hour), true)
+			if name == "tiered" {
+				// L2为共享的redis，L1中的旧值被删除
+				t.Assert(node2.Get(ctx, "hits").String(), "2")
+				node1.Incr(ctx, "hits", time.Hour)
+				t.Assert(node2.Get(ctx, "hits").String(), "3")
+				t.Assert(node2.Get(ctx, "state"), "s1")
+			} else {
+				t.Assert(node1.Incr(ctx, "hits", time.Hour), 2)
+				t.Assert(node2.Get(ctx, "hits").String(), "1")
+				t.Assert(node2.Get(ctx, "state"), "s2")
+			}
+			node1.Clear(ctx)
+			node2.Clear(ctx)
+		})
+	}
+	// 启用消息总线可以与缓存的读写并发
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.New("bus_counter_concurrent_")
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				c.Set(ctx, "a", i, 0)
+			}
+		}()
+		t.AssertNil(c.SetBus(ctx, cache.BusOptions{Bus: bus}))
+		<-done
+		c.Clear(ctx)
+	})
+}
@@ -0,0 +1,83 @@
+/*
+* @desc:比较并交换测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 03:10
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_CompareAndSet(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("cas_")
+	caches["generation"] = cache.New("cas_gen_").SetGeneration(true)
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			v, version := c.GetWithVersion(ctx, "doc")
+			t.Assert(v, nil)
+			t.Assert(version, 0)
+			// 版本0表示键不存在时写入
+			t.Assert(c.CompareAndSet(ctx, "doc", 0, "draft", time.Hour), true)
+			t.Assert(c.CompareAndSet(ctx, "doc", 0, "other", time.Hour), false)
+
+			v, version = c.GetWithVersion(ctx, "doc")
+			t.Assert(v, "draft")
+			t.AssertGT(version, 0)
+			t.Assert(c.CompareAndSet(ctx, "doc", version, "review", time.Hour), true)
+			// 已被其它写入者修改
+			t.Assert(c.CompareAndSet(ctx, "doc", version, "approved", time.Hour), false)
+			t.Assert(c.Get(ctx, "doc"), "review")
+
+			// 其它方法写入也会改变版本
+			_, version = c.GetWithVersion(ctx, "doc")
+			c.Set(ctx, "doc", "rejected", time.Hour)
+			_, changed := c.GetWithVersion(ctx, "doc")
+			t.AssertNE(changed, version)
+			t.Assert(c.CompareAndSet(ctx, "doc", version, "approved", time.Hour), false)
+			t.Assert(c.CompareAndSet(ctx, "doc", changed, "approved", time.Hour), true)
+			t.Assert(c.Get(ctx, "doc"), "approved")
+
+			// 并发的乐观更新不丢失
+			c.Set(ctx, "counter", 0, 0)
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 5; j++ {
+						for {
+							v, version := c.GetWithVersion(ctx, "counter")
+							if c.CompareAndSet(ctx, "counter", version, v.Int()+1, 0) {
+								break
+							}
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			t.Assert(c.Get(ctx, "counter"), 50)
+
+			// 已知不存在的键返回版本，可以被覆盖
+			c.GetOrSetFunc(ctx, "missing", func(ctx context.Context) (interface{}, error) {
+				return cache.NotFound, nil
+			}, time.Hour)
+			_, version, err := c.GetWithVersionE(ctx, "missing")
+			t.Assert(errors.Is(err, cache.ErrAbsent), true)
+			t.AssertGT(version, 0)
+			t.Assert(c.CompareAndSet(ctx, "missing", version, "found", 0), true)
+			t.Assert(c.Get(ctx, "missing"), "found")
+			c.Clear(ctx)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+* @desc:原子计数器测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 23:30
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_Counter(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("counter_")
+	caches["generation"] = cache.New("counter_gen_").SetGeneration(true)
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			// 并发递增不丢失更新
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 10; j++ {
+						c.Incr(ctx, "views", time.Hour)
+					}
+				}()
+			}
+			wg.Wait()
+			t.Assert(c.Get(ctx, "views"), 200)
+			t.Assert(c.IncrBy(ctx, "views", 10), 210)
+			t.Assert(c.Decr(ctx, "views"), 209)
+
+			v, err := c.IncrByFloatE(ctx, "score", 1.5)
+			t.AssertNil(err)
+			t.Assert(v, 1.5)
+			t.Assert(c.IncrByFloat(ctx, "score", 0.25), 1.75)
+			t.Assert(c.Get(ctx, "score"), 1.75)
+
+			c.Set(ctx, "name", "john", 0)
+			_, err = c.IncrE(ctx, "name")
+			t.AssertNE(err, nil)
+			c.Clear(ctx)
+		})
+	}
+}
+
+func Test_CounterTTL(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewRedis("counter_ttl_", testRedisName)
+		// 只在新建时设置过期时间
+		t.Assert(c.Incr(ctx, "quota", time.Minute), 1)
+		redisServer.FastForward(30 * time.Second)
+		t.Assert(c.Incr(ctx, "quota", time.Minute), 2)
+		t.Assert(redisServer.TTL("counter_ttl_quota"), 30*time.Second)
+		t.Assert(c.Incr(ctx, "forever"), 1)
+		t.Assert(redisServer.TTL("counter_ttl_forever"), time.Duration(0))
+		c.Clear(ctx)
+
+		// TTL抖动不影响计数器的过期时间
+		j := cache.NewRedis("counter_ttl_jitter_", testRedisName).SetTTLJitter(0.9)
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("quota%d", i)
+			t.Assert(j.Incr(ctx, key, time.Hour), 1)
+			t.Assert(redisServer.TTL("counter_ttl_jitter_"+key), time.Hour)
+		}
+		j.Clear(ctx)
+
+		m := cache.New("counter_ttl_")
+		t.Assert(m.Incr(ctx, "quota", 200*time.Millisecond), 1)
+		t.Assert(m.Incr(ctx, "quota", time.Hour), 2)
+		time.Sleep(300 * time.Millisecond)
+		_, err := m.GetE(ctx, "quota")
+		t.Assert(errors.Is(err, cache.ErrNotFound), true)
+		t.Assert(m.Incr(ctx, "quota"), 1)
+		m.Clear(ctx)
+	})
+}
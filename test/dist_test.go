@@ -0,0 +1,75 @@
+/*
+* @desc:磁盘缓存适配器测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 23:20
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/adapter"
+)
+
+func Test_DistEmptyValue(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		d := adapter.NewDist()
+		// 空值和0是有效的缓存值，不视为不存在
+		t.AssertNil(d.Set(ctx, "dist_empty_key", "", 0))
+		v, err := d.GetOrSet(ctx, "dist_empty_key", "x", 0)
+		t.AssertNil(err)
+		t.Assert(v.String(), "")
+		ok, err := d.Contains(ctx, "dist_empty_key")
+		t.AssertNil(err)
+		t.Assert(ok, true)
+		t.AssertNil(d.Set(ctx, "dist_zero_key", 0, 0))
+		v, err = d.GetOrSetFunc(ctx, "dist_zero_key", func(ctx context.Context) (interface{}, error) {
+			return 1, nil
+		}, 0)
+		t.AssertNil(err)
+		t.Assert(v.Int(), 0)
+		_, err = d.Remove(ctx, "dist_empty_key", "dist_zero_key")
+		t.AssertNil(err)
+	})
+}
+
+func Test_DistGetOrSetFunc(t *testing.T) {
+	ctx := context.Background()
+	d := adapter.NewDist()
+	gtest.C(t, func(t *gtest.T) {
+		var calls int32
+		loadErr := errors.New("db timeout")
+		// 加载失败或结果为nil时不写入，下次调用重新加载
+		v, err := d.GetOrSetFunc(ctx, "dist_func_key", func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, loadErr
+		}, 0)
+		t.Assert(errors.Is(err, loadErr), true)
+		t.Assert(v.IsNil(), true)
+		v, err = d.GetOrSetFunc(ctx, "dist_func_key", func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, nil
+		}, 0)
+		t.AssertNil(err)
+		t.Assert(v.IsNil(), true)
+		ok, err := d.Contains(ctx, "dist_func_key")
+		t.AssertNil(err)
+		t.Assert(ok, false)
+		v, err = d.GetOrSetFunc(ctx, "dist_func_key", func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return "v", nil
+		}, 0)
+		t.AssertNil(err)
+		t.Assert(v, "v")
+		t.Assert(atomic.LoadInt32(&calls), 3)
+		_, err = d.Remove(ctx, "dist_func_key")
+		t.AssertNil(err)
+	})
+}
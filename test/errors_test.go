@@ -0,0 +1,68 @@
+/*
+* @desc:错误返回测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 10:50
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gogf/gf/v2/database/gredis"
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_ErrorReturning(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("errors_") {
+		gtest.C(t, func(t *gtest.T) {
+			var ce cache.IGCacheE = c
+			_, err := ce.GetE(ctx, "none")
+			t.Assert(errors.Is(err, cache.ErrNotFound), true)
+
+			t.AssertNil(ce.SetE(ctx, "k", "v", 0, "t"))
+			v, err := ce.GetE(ctx, "k")
+			t.AssertNil(err)
+			t.Assert(v.String(), "v")
+
+			ok, err := ce.ContainsE(ctx, "k")
+			t.AssertNil(err)
+			t.Assert(ok, true)
+
+			t.AssertNil(ce.RemoveByTagE(ctx, "t"))
+			_, err = ce.GetE(ctx, "k")
+			t.Assert(errors.Is(err, cache.ErrNotFound), true)
+
+			loadErr := errors.New("db timeout")
+			_, err = ce.GetOrSetFuncE(ctx, "f", func(ctx context.Context) (interface{}, error) {
+				return nil, loadErr
+			}, 0, "")
+			t.Assert(errors.Is(err, loadErr), true)
+			t.Assert(errors.Is(err, cache.ErrBackendUnavailable), false)
+		})
+	}
+	// 损坏的标签索引
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.New("errors_decode_")
+		c.Set(ctx, "tag_broken", "not json", 0)
+		err := c.RemoveByTagE(ctx, "broken")
+		t.Assert(errors.Is(err, cache.ErrDecode), true)
+	})
+	// redis 不可用
+	gtest.C(t, func(t *gtest.T) {
+		gredis.SetConfig(&gredis.Config{
+			Address: "127.0.0.1:1",
+		}, "errors_unavailable")
+		c := cache.NewRedis("errors_unavailable_", "errors_unavailable")
+		_, err := c.GetE(ctx, "k")
+		t.Assert(errors.Is(err, cache.ErrBackendUnavailable), true)
+		t.Assert(errors.Is(err, cache.ErrNotFound), false)
+		err = c.SetE(ctx, "k", "v", 0)
+		t.Assert(errors.Is(err, cache.ErrBackendUnavailable), true)
+	})
+}
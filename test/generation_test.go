@@ -0,0 +1,148 @@
+/*
+* @desc:版本号失效测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 16:40
+ */
+
+package test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+	"github.com/tiger1103/gfast-cache/ratelimit"
+)
+
+func Test_Generation(t *testing.T) {
+	ctx := context.Background()
+	type user struct {
+		Name string
+		Age  int
+	}
+	for _, c := range newCaches("generation_") {
+		c.SetGeneration(true)
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "a", "1", 0, "t1", "t2")
+			c.Set(ctx, "b", 2, 0, "t2")
+			c.Set(ctx, "c", 3, 0)
+			t.Assert(c.Get(ctx, "a"), "1")
+			t.Assert(c.Get(ctx, "b").Int(), 2)
+			t.Assert(c.ListTags(ctx), []string{"t1", "t2"})
+			t.Assert(c.KeysByTag(ctx, "t2"), []string{"a", "b"})
+			t.Assert(c.TagsOfKey(ctx, "a"), []string{"t1", "t2"})
+			t.Assert(c.Size(ctx), 3)
+
+			// 标签失效只递增版本号
+			c.RemoveByTag(ctx, "t1")
+			t.Assert(c.Contains(ctx, "a"), false)
+			t.Assert(c.Contains(ctx, "b"), true)
+			t.Assert(c.Size(ctx), 2)
+			t.Assert(c.SetIfNotExist(ctx, "a", "2", 0, "t1"), true)
+			t.Assert(c.Get(ctx, "a"), "2")
+			t.Assert(c.SetIfNotExist(ctx, "a", "3", 0), false)
+
+			// 整个前缀失效
+			c.Invalidate(ctx)
+			t.Assert(c.Contains(ctx, "a"), false)
+			t.Assert(c.Contains(ctx, "c"), false)
+			t.Assert(c.Size(ctx), 0)
+			v := c.GetOrSetFunc(ctx, "c", func(ctx context.Context) (interface{}, error) {
+				return 4, nil
+			}, 0, "t2")
+			t.Assert(v.Int(), 4)
+			t.Assert(c.GetOrSet(ctx, "c", 5, 0).Int(), 4)
+			t.Assert(c.Remove(ctx, "c").Int(), 4)
+			t.Assert(c.Contains(ctx, "c"), false)
+		})
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "dept3", 1, 0, "org:1/dept:3")
+			c.Set(ctx, "org2", 1, 0, "org:2")
+			c.RemoveByTagPattern(ctx, "org:1/*")
+			t.Assert(c.Contains(ctx, "dept3"), false)
+			t.Assert(c.Contains(ctx, "org2"), true)
+
+			users := cache.NewTyped[user](c)
+			t.AssertNil(users.Set(ctx, "u", user{Name: "zhangsan", Age: 18}, 0, "user"))
+			u, ok, err := users.Get(ctx, "u")
+			t.AssertNil(err)
+			t.Assert(ok, true)
+			t.Assert(u, user{Name: "zhangsan", Age: 18})
+			t.AssertNil(c.ClearE(ctx))
+		})
+	}
+}
+
+func Test_GenerationTagTTL(t *testing.T) {
+	ctx := context.Background()
+	for name, c := range newCaches("generation_ttl_") {
+		c.SetGeneration(true)
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "a", "1", time.Hour, "t1")
+			c.Set(ctx, "b", "2", time.Hour, "t2")
+			t.Assert(c.ListTags(ctx), []string{"t1", "t2"})
+
+			// 删除的标签和没有有效缓存的标签不再列出
+			c.RemoveByTag(ctx, "t1")
+			t.Assert(c.ListTags(ctx), []string{"t2"})
+			c.Remove(ctx, "b")
+			t.Assert(c.ListTags(ctx), []string{})
+			c.RemoveByTag(ctx, "none")
+			c.Set(ctx, "c", "3", 0, "t3")
+			t.Assert(c.ListTags(ctx), []string{"t3"})
+			t.Assert(c.Get(ctx, "c"), "3")
+
+			if name == "redis" {
+				// 标签版本号随记录它的缓存过期，删除不存在的标签不创建版本号
+				ttl := redisServer.TTL("generation_ttl___gen_tag_t1")
+				t.Assert(ttl > time.Hour && ttl <= time.Hour+time.Minute, true)
+				t.Assert(redisServer.Exists("generation_ttl___gen_tag_none"), false)
+				t.Assert(redisServer.TTL("generation_ttl___gen_tag_t3"), time.Duration(0))
+				c.Set(ctx, "c", "3", time.Second, "t3")
+				t.Assert(redisServer.TTL("generation_ttl___gen_tag_t3"), time.Duration(0))
+				c.Set(ctx, "d", "4", 2*time.Hour, "t1")
+				t.Assert(redisServer.TTL("generation_ttl___gen_tag_t1") > 2*time.Hour, true)
+			}
+			t.AssertNil(c.ClearE(ctx))
+		})
+	}
+}
+
+// 内部键不计入键列表，并发写入已失效的条目只有一个成功
+func Test_GenerationSetIfNotExist(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("generation_nx_") {
+		c.SetGeneration(true)
+		gtest.C(t, func(t *gtest.T) {
+			_, err := ratelimit.NewFixedWindow(c, 3, time.Hour).Allow(ctx, "user:1")
+			t.AssertNil(err)
+			c.Set(ctx, "a", "1", time.Hour, "t1")
+			t.Assert(c.KeyStrings(ctx), []string{"a"})
+
+			for i := 0; i < 20; i++ {
+				c.RemoveByTag(ctx, "t1")
+				var (
+					wg      sync.WaitGroup
+					winners int32
+				)
+				for j := 0; j < 8; j++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if c.SetIfNotExist(ctx, "a", j, time.Hour, "t1") {
+							atomic.AddInt32(&winners, 1)
+						}
+					}()
+				}
+				wg.Wait()
+				t.Assert(atomic.LoadInt32(&winners), 1)
+			}
+			t.AssertNil(c.ClearE(ctx))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+* @desc:哈希字段操作测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 00:50
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_Hash(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("hash_")
+	caches["generation"] = cache.New("hash_gen_").SetGeneration(true)
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			c.HSet(ctx, "user:1", g.Map{"name": "john", "age": 18}, time.Hour, "user")
+			c.HSet(ctx, "user:1", g.Map{"age": 19}, time.Hour)
+			t.Assert(c.HGet(ctx, "user:1", "name"), "john")
+			t.Assert(c.HGet(ctx, "user:1", "age"), 19)
+			_, err := c.HGetE(ctx, "user:1", "email")
+			t.Assert(errors.Is(err, cache.ErrNotFound), true)
+
+			all := c.HGetAll(ctx, "user:1")
+			t.Assert(len(all), 2)
+			t.Assert(all["name"], "john")
+			t.Assert(len(c.HGetAll(ctx, "user:2")), 0)
+
+			// 并发递增不丢失更新
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 10; j++ {
+						c.HIncrBy(ctx, "user:1", "visits", 1)
+					}
+				}()
+			}
+			wg.Wait()
+			t.Assert(c.HGet(ctx, "user:1", "visits"), 100)
+			t.Assert(c.HIncrBy(ctx, "user:1", "age", -1), 18)
+			_, err = c.HIncrByE(ctx, "user:1", "name", 1)
+			t.AssertNE(err, nil)
+
+			t.Assert(c.HDel(ctx, "user:1", "visits", "email"), 1)
+			t.Assert(c.HGet(ctx, "user:1", "visits"), nil)
+
+			// 哈希与普通缓存一起遍历，但没有值
+			c.Set(ctx, "plain", "v", 0)
+			keys := c.KeyStrings(ctx)
+			sort.Strings(keys)
+			if c.CachePrefix != "hash_gen_" {
+				t.Assert(keys, []string{"plain", "user:1"})
+			}
+			t.Assert(len(c.Values(ctx)), 1)
+
+			// 按标签删除
+			c.RemoveByTag(ctx, "user")
+			t.Assert(len(c.HGetAll(ctx, "user:1")), 0)
+			t.Assert(c.HGet(ctx, "user:1", "name"), nil)
+
+			// 删除全部字段后哈希被删除
+			c.HSet(ctx, "user:3", g.Map{"name": "tom"}, 0)
+			t.Assert(c.HDel(ctx, "user:3", "name"), 1)
+			t.Assert(len(c.HGetAll(ctx, "user:3")), 0)
+
+			c.HSet(ctx, "user:4", g.Map{"name": "lily"}, 0)
+			c.Remove(ctx, "user:4")
+			t.Assert(c.HGet(ctx, "user:4", "name"), nil)
+
+			// 批量删除哈希和普通缓存
+			c.HSet(ctx, "user:5", g.Map{"name": "lucy", "age": 18}, 0)
+			c.HSet(ctx, "user:6", g.Map{"name": "lilei"}, 0)
+			c.Removes(ctx, []string{"user:5", "plain", "user:6"})
+			t.Assert(len(c.HGetAll(ctx, "user:5")), 0)
+			t.Assert(len(c.HGetAll(ctx, "user:6")), 0)
+			t.Assert(c.Contains(ctx, "plain"), false)
+			c.Clear(ctx)
+		})
+	}
+}
+
+func Test_HashTTL(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewRedis("hash_ttl_", testRedisName)
+		c.HSet(ctx, "h", g.Map{"a": 1}, time.Minute)
+		redisServer.FastForward(30 * time.Second)
+		// 与Set一致，写入时重置整个哈希的过期时间
+		c.HSet(ctx, "h", g.Map{"b": 2}, time.Minute)
+		redisServer.FastForward(40 * time.Second)
+		t.Assert(c.HGet(ctx, "h", "a"), 1)
+		// 递增不延长过期时间
+		c.HIncrBy(ctx, "h", "a", 1, time.Hour)
+		redisServer.FastForward(30 * time.Second)
+		t.Assert(len(c.HGetAll(ctx, "h")), 0)
+		c.Clear(ctx)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		// TTL抖动不影响哈希的过期时间
+		c := cache.NewRedis("hash_ttl_jitter_", testRedisName).SetTTLJitter(0.9)
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("h%d", i)
+			c.HSet(ctx, key, g.Map{"a": 1}, time.Hour)
+			t.Assert(redisServer.TTL("hash_ttl_jitter_"+key), time.Hour)
+			c.HIncrBy(ctx, "n"+key, "a", 1, time.Hour)
+			t.Assert(redisServer.TTL("hash_ttl_jitter_n"+key), time.Hour)
+		}
+		c.Clear(ctx)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.New("hash_ttl_")
+		c.HSet(ctx, "h", g.Map{"a": 1}, 100*time.Millisecond)
+		c.HIncrBy(ctx, "h", "a", 1, time.Hour)
+		t.Assert(c.HGet(ctx, "h", "a"), 2)
+		time.Sleep(200 * time.Millisecond)
+		t.Assert(c.HGet(ctx, "h", "a"), nil)
+		c.Clear(ctx)
+	})
+}
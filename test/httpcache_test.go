@@ -0,0 +1,184 @@
+/*
+* @desc:http响应缓存中间件测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 05:20
+ */
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/gogf/gf/v2/util/guid"
+	"github.com/tiger1103/gfast-cache/httpcache"
+)
+
+func Test_HTTPCache(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("hc_")
+	s := g.Server(guid.S())
+	var calls int64
+	handler := func(r *ghttp.Request) {
+		n := atomic.AddInt64(&calls, 1)
+		r.Response.Writef("%s %s %d", r.URL.Path, r.Header.Get("Accept-Language"), n)
+	}
+	for name, c := range caches {
+		s.Group("/"+name, func(group *ghttp.RouterGroup) {
+			group.Middleware(httpcache.Middleware(c, httpcache.Options{
+				TTL:     time.Hour,
+				Tags:    []string{"dict"},
+				Headers: []string{"Accept-Language"},
+				UserFunc: func(r *ghttp.Request) string {
+					return r.Header.Get("X-User")
+				},
+			}), ghttp.MiddlewareHandlerResponse)
+			group.GET("/dict", handler)
+			group.GET("/nostore", func(r *ghttp.Request) {
+				r.Response.Header().Set("Cache-Control", "no-store")
+				handler(r)
+			})
+			group.GET("/error", func(r *ghttp.Request) {
+				atomic.AddInt64(&calls, 1)
+				r.SetError(gerror.New("failed"))
+			})
+		})
+	}
+	s.SetDumpRouterMap(false)
+	s.Start()
+	defer s.Shutdown()
+	time.Sleep(100 * time.Millisecond)
+
+	client := g.Client()
+	client.SetPrefix(fmt.Sprintf("http://127.0.0.1:%d", s.GetListenedPort()))
+	get := func(url string, header map[string]string) (status int, body string, resp *http.Response) {
+		r, err := client.Header(header).Get(ctx, url)
+		if err != nil {
+			panic(err)
+		}
+		defer r.Close()
+		return r.StatusCode, r.ReadAllString(), r.Response
+	}
+	for name, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			calls = 0
+			_, body, resp := get("/"+name+"/dict?b=2&a=1", nil)
+			t.Assert(body, "/"+name+"/dict  1")
+			t.Assert(resp.Header.Get(httpcache.HeaderCache), "MISS")
+			etag := resp.Header.Get("ETag")
+			t.AssertNE(etag, "")
+
+			// 参数顺序不同的请求共享响应
+			status, body, resp := get("/"+name+"/dict?a=1&b=2", nil)
+			t.Assert(status, http.StatusOK)
+			t.Assert(body, "/"+name+"/dict  1")
+			t.Assert(resp.Header.Get(httpcache.HeaderCache), "HIT")
+			t.Assert(resp.Header.Get("ETag"), etag)
+			t.AssertNE(resp.Header.Get("Age"), "")
+			t.Assert(resp.Header.Get("Content-Type"), "text/plain; charset=utf-8")
+
+			// 匹配的ETag返回304
+			status, body, _ = get("/"+name+"/dict?a=1&b=2", map[string]string{"If-None-Match": etag})
+			t.Assert(status, http.StatusNotModified)
+			t.Assert(body, "")
+
+			// 按请求头和用户区分响应
+			_, body, _ = get("/"+name+"/dict?a=1&b=2", map[string]string{"Accept-Language": "en"})
+			t.Assert(body, "/"+name+"/dict en 2")
+			_, body, _ = get("/"+name+"/dict?a=1&b=2", map[string]string{"X-User": "1"})
+			t.Assert(body, "/"+name+"/dict  3")
+			_, body, _ = get("/"+name+"/dict?a=1&b=2", map[string]string{"X-User": "1"})
+			t.Assert(body, "/"+name+"/dict  3")
+
+			// 请求no-cache时刷新缓存
+			_, body, _ = get("/"+name+"/dict?a=1&b=2", map[string]string{"Cache-Control": "no-cache"})
+			t.Assert(body, "/"+name+"/dict  4")
+			_, body, _ = get("/"+name+"/dict?a=1&b=2", nil)
+			t.Assert(body, "/"+name+"/dict  4")
+
+			// 按标签失效
+			t.AssertNil(c.RemoveByTagE(ctx, "dict"))
+			_, body, resp = get("/"+name+"/dict?a=1&b=2", nil)
+			t.Assert(body, "/"+name+"/dict  5")
+			t.Assert(resp.Header.Get(httpcache.HeaderCache), "MISS")
+
+			// 响应禁止缓存或出错时不缓存
+			get("/"+name+"/nostore", nil)
+			_, body, resp = get("/"+name+"/nostore", nil)
+			t.Assert(body, "/"+name+"/nostore  7")
+			t.Assert(resp.Header.Get(httpcache.HeaderCache), "")
+			get("/"+name+"/error", nil)
+			get("/"+name+"/error", nil)
+			t.Assert(atomic.LoadInt64(&calls), 9)
+			c.Clear(ctx)
+		})
+	}
+}
+
+func Test_HTTPCacheCredentials(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("hc_auth_")
+	s := g.Server(guid.S())
+	handler := func(r *ghttp.Request) {
+		r.Response.Write("menus of " + r.Header.Get("Authorization"))
+	}
+	for name, c := range caches {
+		s.Group("/"+name, func(group *ghttp.RouterGroup) {
+			group.Middleware(httpcache.Middleware(c, httpcache.Options{TTL: time.Hour}))
+			group.GET("/menus", handler)
+			group.GET("/public", func(r *ghttp.Request) {
+				r.Response.Header().Set("Cache-Control", "public")
+				handler(r)
+			})
+		})
+	}
+	s.SetDumpRouterMap(false)
+	s.Start()
+	defer s.Shutdown()
+	time.Sleep(100 * time.Millisecond)
+
+	client := g.Client()
+	client.SetPrefix(fmt.Sprintf("http://127.0.0.1:%d", s.GetListenedPort()))
+	get := func(url, user string) (body, cached string) {
+		r, err := client.Header(map[string]string{"Authorization": user}).Get(ctx, url)
+		if err != nil {
+			panic(err)
+		}
+		defer r.Close()
+		return r.ReadAllString(), r.Header.Get(httpcache.HeaderCache)
+	}
+	for name, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			// 未按用户缓存时，带凭证请求的响应不缓存，也不返回其它请求缓存的响应
+			body, cached := get("/"+name+"/menus", "alice")
+			t.Assert(body, "menus of alice")
+			t.Assert(cached, "")
+			body, cached = get("/"+name+"/menus", "bob")
+			t.Assert(body, "menus of bob")
+			t.Assert(cached, "")
+			body, cached = get("/"+name+"/menus", "")
+			t.Assert(body, "menus of ")
+			t.Assert(cached, "MISS")
+			body, _ = get("/"+name+"/menus", "bob")
+			t.Assert(body, "menus of bob")
+
+			// 明确声明为public的响应可以共享
+			body, cached = get("/"+name+"/public", "alice")
+			t.Assert(body, "menus of alice")
+			t.Assert(cached, "MISS")
+			body, cached = get("/"+name+"/public", "bob")
+			t.Assert(body, "menus of alice")
+			t.Assert(cached, "HIT")
+			c.Clear(ctx)
+		})
+	}
+}
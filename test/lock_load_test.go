@@ -0,0 +1,77 @@
+/*
+* @desc:分布式加载锁测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 19:30
+ */
+
+package test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_RedisLoadLock(t *testing.T) {
+	ctx := context.Background()
+	const prefix = "load_lock_"
+	c := cache.NewRedis(prefix, testRedisName).SetLoadLock(cache.LoadLockOptions{
+		TTL:      time.Second,
+		Wait:     300 * time.Millisecond,
+		Interval: 10 * time.Millisecond,
+	})
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		// 持有锁期间锁带有过期时间
+		if redisServer.TTL(prefix+"__lock_k") <= 0 {
+			return nil, nil
+		}
+		return "loaded", nil
+	}
+	gtest.C(t, func(t *gtest.T) {
+		// 其它进程持有锁并写入缓存，不调用加载函数
+		redisServer.Set(prefix+"__lock_k", "other")
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			c.Set(ctx, "k", "other", 0)
+			redisServer.Del(prefix + "__lock_k")
+		}()
+		t.Assert(c.GetOrSetFuncLock(ctx, "k", loader, 0), "other")
+		t.Assert(atomic.LoadInt32(&calls), 0)
+		c.Remove(ctx, "k")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		// 其它进程释放锁但没有写入缓存，获得锁后加载
+		redisServer.Set(prefix+"__lock_k", "other")
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			redisServer.Del(prefix + "__lock_k")
+		}()
+		t.Assert(c.GetOrSetFuncLock(ctx, "k", loader, 0), "loaded")
+		t.Assert(atomic.LoadInt32(&calls), 1)
+		t.Assert(redisServer.Exists(prefix+"__lock_k"), false)
+		c.Remove(ctx, "k")
+	})
+	gtest.C(t, func(t *gtest.T) {
+		// 等待超时后直接加载，不释放其它进程的锁
+		calls = 0
+		redisServer.Set(prefix+"__lock_k", "other")
+		begin := time.Now()
+		t.Assert(c.GetOrSetFuncLock(ctx, "k", func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return "timeout", nil
+		}, 0), "timeout")
+		t.Assert(time.Since(begin) >= 300*time.Millisecond, true)
+		t.Assert(atomic.LoadInt32(&calls), 1)
+		v, _ := redisServer.Get(prefix + "__lock_k")
+		t.Assert(v, "other")
+		redisServer.Del(prefix + "__lock_k")
+		c.Remove(ctx, "k")
+	})
+}
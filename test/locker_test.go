@@ -0,0 +1,85 @@
+/*
+* @desc:分布式锁测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 02:10
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_Locker(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("locker_") {
+		gtest.C(t, func(t *gtest.T) {
+			locker := c.Locker()
+			lock, err := locker.TryLock(ctx, "job", 3*time.Second)
+			t.AssertNil(err)
+			t.Assert(lock.Name(), "job")
+			_, err = locker.TryLock(ctx, "job", 3*time.Second)
+			t.Assert(errors.Is(err, cache.ErrLockHeld), true)
+
+			// 锁不受Clear影响
+			c.Clear(ctx)
+			_, err = locker.TryLock(ctx, "job", 3*time.Second)
+			t.Assert(errors.Is(err, cache.ErrLockHeld), true)
+
+			// 等待锁释放
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				_ = lock.Unlock(ctx)
+			}()
+			next, err := locker.Lock(ctx, "job", 3*time.Second)
+			t.AssertNil(err)
+			t.Assert(next.Token(), lock.Token()+1)
+			<-lock.Done()
+			t.Assert(errors.Is(lock.Unlock(ctx), cache.ErrLockNotHeld), true)
+
+			timeout, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			_, err = locker.Lock(timeout, "job", 3*time.Second)
+			t.Assert(errors.Is(err, context.DeadlineExceeded), true)
+			t.AssertNil(next.Unlock(ctx))
+		})
+	}
+}
+
+func Test_LockerRenew(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		locker := cache.New("locker_renew_").Locker()
+		lock, err := locker.TryLock(ctx, "job", 150*time.Millisecond)
+		t.AssertNil(err)
+		// 持有期间自动续期
+		time.Sleep(400 * time.Millisecond)
+		_, err = locker.TryLock(ctx, "job", time.Second)
+		t.Assert(errors.Is(err, cache.ErrLockHeld), true)
+		t.AssertNil(lock.Unlock(ctx))
+	})
+	gtest.C(t, func(t *gtest.T) {
+		locker := cache.NewRedis("locker_renew_", testRedisName).Locker()
+		lock, err := locker.TryLock(ctx, "job", 150*time.Millisecond)
+		t.AssertNil(err)
+		// 锁被其它持有者获得后续期失败，解锁不会释放其它持有者的锁
+		key := "__gfcache_lock:locker_renew_job"
+		redisServer.Set(key, "other")
+		select {
+		case <-lock.Done():
+		case <-time.After(time.Second):
+			t.Error("lease not lost")
+		}
+		t.Assert(errors.Is(lock.Unlock(ctx), cache.ErrLockNotHeld), true)
+		v, _ := redisServer.Get(key)
+		t.Assert(v, "other")
+		redisServer.Del(key)
+	})
+}
@@ -0,0 +1,54 @@
+/*
+* @desc:测试初始化
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 9:40
+ */
+
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gogf/gf/v2/database/gredis"
+	"github.com/tiger1103/gfast-cache/adapter"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+// 测试使用的进程内redis
+const testRedisName = "miniredis"
+
+var redisServer *miniredis.Miniredis
+
+func TestMain(m *testing.M) {
+	// 磁盘缓存使用临时目录
+	dir, err := os.MkdirTemp("", "gfast-cache-dist")
+	if err != nil {
+		panic(err)
+	}
+	adapter.SetConfig(&adapter.Config{
+		Dir: dir,
+	})
+	redisServer, err = miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	gredis.SetConfig(&gredis.Config{
+		Address: redisServer.Addr(),
+	}, testRedisName)
+	code := m.Run()
+	redisServer.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// 每个用例使用独立的前缀，避免共享实例间相互影响
+func newCaches(prefix string) map[string]*cache.GfCache {
+	return map[string]*cache.GfCache{
+		"memory": cache.New(prefix),
+		"redis":  cache.NewRedis(prefix, testRedisName),
+		"dist":   cache.NewDist(prefix),
+	}
+}
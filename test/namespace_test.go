@@ -0,0 +1,71 @@
+/*
+* @desc:缓存前缀隔离测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 15:50
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_Namespace(t *testing.T) {
+	ctx := context.Background()
+	others := newCaches("ns_other_")
+	for name, c := range newCaches("ns_self_") {
+		other := others[name]
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "a", "1", 0, "t")
+			c.Set(ctx, "b", "2", 0)
+			other.Set(ctx, "c", "3", 0, "t")
+
+			keys := c.KeyStrings(ctx)
+			sort.Strings(keys)
+			t.Assert(keys, []string{"a", "b"})
+			t.Assert(len(c.Keys(ctx)), 2)
+			t.Assert(c.Size(ctx), 2)
+
+			data := c.Data(ctx)
+			t.Assert(len(data), 2)
+			t.Assert(gconv.String(data["a"]), "1")
+			t.Assert(gconv.String(data["b"]), "2")
+			values := gconv.Strings(c.Values(ctx))
+			sort.Strings(values)
+			t.Assert(values, []string{"1", "2"})
+
+			// 只清空当前前缀
+			t.AssertNil(c.ClearE(ctx))
+			t.Assert(c.Size(ctx), 0)
+			t.Assert(c.ListTags(ctx), []string{})
+			t.Assert(other.Size(ctx), 1)
+			t.Assert(other.KeysByTag(ctx, "t"), []string{"c"})
+			other.Clear(ctx)
+			t.Assert(other.Size(ctx), 0)
+		})
+	}
+}
+
+func Test_NamespaceEmptyPrefix(t *testing.T) {
+	ctx := context.Background()
+	others := newCaches("ns_kept_")
+	for name, c := range newCaches("") {
+		other := others[name]
+		gtest.C(t, func(t *gtest.T) {
+			other.Set(ctx, "a", "1", 0)
+			// 空前缀不清空整个后端
+			t.Assert(errors.Is(c.ClearE(ctx), cache.ErrEmptyPrefix), true)
+			c.Clear(ctx)
+			t.Assert(other.Get(ctx, "a"), "1")
+			other.Clear(ctx)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+* @desc:已知不存在的键缓存测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 21:30
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_NegativeCache(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("negative_") {
+		gtest.C(t, func(t *gtest.T) {
+			var calls int32
+			load := func(ctx context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				return cache.NotFound, nil
+			}
+			v, err := c.GetOrSetFuncE(ctx, "user:404", load, time.Hour, "user")
+			t.AssertNil(v)
+			t.Assert(errors.Is(err, cache.ErrAbsent), true)
+			t.Assert(errors.Is(err, cache.ErrNotFound), true)
+			t.AssertNil(c.GetOrSetFunc(ctx, "user:404", load, time.Hour, "user"))
+			t.Assert(atomic.LoadInt32(&calls), 1)
+
+			// 已知不存在与未知分开报告
+			_, err = c.GetE(ctx, "user:404")
+			t.Assert(errors.Is(err, cache.ErrAbsent), true)
+			_, err = c.GetE(ctx, "user:unknown")
+			t.Assert(errors.Is(err, cache.ErrNotFound), true)
+			t.Assert(errors.Is(err, cache.ErrAbsent), false)
+			ok, err := c.ContainsE(ctx, "user:404")
+			t.Assert(ok, false)
+			t.Assert(errors.Is(err, cache.ErrAbsent), true)
+			ok, err = c.ContainsE(ctx, "user:unknown")
+			t.Assert(ok, false)
+			t.AssertNil(err)
+			t.AssertNil(c.Get(ctx, "user:404"))
+			t.Assert(c.Contains(ctx, "user:404"), false)
+			t.Assert(len(c.Data(ctx)), 0)
+
+			// 按标签清除
+			c.RemoveByTag(ctx, "user")
+			t.AssertNil(c.GetOrSetFunc(ctx, "user:404", load, time.Hour, "user"))
+			t.Assert(atomic.LoadInt32(&calls), 2)
+
+			// 写入后不再是已知不存在
+			c.Set(ctx, "user:404", "created", time.Hour)
+			t.Assert(c.Get(ctx, "user:404"), "created")
+			t.Assert(c.Contains(ctx, "user:404"), true)
+			c.Clear(ctx)
+		})
+	}
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.New("negative_ttl_").SetNegativeTTL(200 * time.Millisecond)
+		var calls int32
+		load := func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return cache.NotFound, nil
+		}
+		t.AssertNil(c.GetOrSetFunc(ctx, "user:404", load, time.Hour))
+		t.AssertNil(c.GetOrSetFunc(ctx, "user:404", load, time.Hour))
+		t.Assert(atomic.LoadInt32(&calls), 1)
+		// 过期后重新加载
+		time.Sleep(300 * time.Millisecond)
+		t.AssertNil(c.GetOrSetFunc(ctx, "user:404", load, time.Hour))
+		t.Assert(atomic.LoadInt32(&calls), 2)
+		c.Clear(ctx)
+	})
+}
+
+func Test_NegativeCacheWriteIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("negative_nx_")
+	caches["generation"] = cache.New("negative_nx_gen_").SetGeneration(true)
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			absent := func(ctx context.Context) (interface{}, error) {
+				return cache.NotFound, nil
+			}
+			c.GetOrSetFunc(ctx, "user:1", absent, time.Hour)
+			c.GetOrSetFunc(ctx, "user:2", absent, time.Hour)
+
+			// 已知不存在的键可以被写入
+			v, err := c.GetOrSetE(ctx, "user:1", "created", time.Hour, "user")
+			t.AssertNil(err)
+			t.Assert(v, "created")
+			t.Assert(c.Get(ctx, "user:1"), "created")
+			t.Assert(c.KeysByTag(ctx, "user"), []string{"user:1"})
+			t.Assert(c.SetIfNotExist(ctx, "user:2", "created", time.Hour), true)
+			t.Assert(c.Get(ctx, "user:2"), "created")
+			t.Assert(c.SetIfNotExist(ctx, "user:2", "other", time.Hour), false)
+			c.Clear(ctx)
+		})
+	}
+}
+
+// 已知不存在和过期保留的条目不计入键列表和数量
+func Test_NegativeCacheKeys(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("negative_keys_")
+	caches["generation"] = cache.New("negative_keys_gen_").SetGeneration(true)
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			c.SetStaleIfError(time.Minute)
+			c.GetOrSetFunc(ctx, "user:404", func(ctx context.Context) (interface{}, error) {
+				return cache.NotFound, nil
+			}, time.Hour)
+			c.GetOrSetFunc(ctx, "order", func(ctx context.Context) (interface{}, error) {
+				return "v1", nil
+			}, 200*time.Millisecond)
+			c.Set(ctx, "user:1", "u1", time.Hour)
+			time.Sleep(300 * time.Millisecond)
+
+			t.Assert(c.KeyStrings(ctx), []string{"user:1"})
+			t.Assert(c.Keys(ctx), []interface{}{"user:1"})
+			t.Assert(c.Size(ctx), 1)
+			t.Assert(len(c.Data(ctx)), 1)
+			c.Clear(ctx)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+/*
+* @desc:限流测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/17 04:30
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/adapter"
+	"github.com/tiger1103/gfast-cache/cache"
+	"github.com/tiger1103/gfast-cache/ratelimit"
+)
+
+func Test_RateLimit(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("rl_")
+	caches["generation"] = cache.New("rl_gen_").SetGeneration(true)
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			// 固定窗口
+			fw := ratelimit.NewFixedWindow(c, 3, time.Hour)
+			for i := int64(1); i <= 3; i++ {
+				r, err := fw.Allow(ctx, "user:1")
+				t.AssertNil(err)
+				t.Assert(r.Allowed, true)
+				t.Assert(r.Limit, 3)
+				t.Assert(r.Remaining, 3-i)
+			}
+			r, err := fw.Allow(ctx, "user:1")
+			t.AssertNil(err)
+			t.Assert(r.Allowed, false)
+			t.Assert(r.Remaining, 0)
+			t.Assert(r.ResetAt.After(time.Now()), true)
+			t.AssertGT(r.RetryAfter, 0)
+			t.AssertLE(r.RetryAfter, time.Hour)
+			// 其它键的配额独立
+			r, _ = fw.Allow(ctx, "user:2")
+			t.Assert(r.Allowed, true)
+			// 超过配额的请求数永远不会被允许
+			r, _ = fw.AllowN(ctx, "user:3", 4)
+			t.Assert(r.Allowed, false)
+			t.Assert(r.RetryAfter, time.Duration(0))
+			t.Assert(r.Remaining, 3)
+
+			// 滑动窗口
+			sw := ratelimit.NewSlidingWindow(c, 3, time.Hour)
+			r, _ = sw.AllowN(ctx, "user:1", 2)
+			t.Assert(r.Allowed, true)
+			t.Assert(r.Remaining, 1)
+			r, _ = sw.AllowN(ctx, "user:1", 2)
+			t.Assert(r.Allowed, false)
+			t.Assert(r.Remaining, 1)
+			t.AssertGT(r.RetryAfter, 0)
+			r, _ = sw.Allow(ctx, "user:1")
+			t.Assert(r.Allowed, true)
+			t.Assert(r.Remaining, 0)
+			t.Assert(r.ResetAt.After(time.Now().Add(time.Hour)), true)
+
+			// 令牌桶
+			tb := ratelimit.NewTokenBucket(c, 10, 2)
+			for i := 0; i < 2; i++ {
+				r, _ = tb.Allow(ctx, "user:1")
+				t.Assert(r.Allowed, true)
+			}
+			r, _ = tb.Allow(ctx, "user:1")
+			t.Assert(r.Allowed, false)
+			t.Assert(r.Limit, 2)
+			t.AssertGT(r.RetryAfter, 0)
+			t.AssertLE(r.RetryAfter, 100*time.Millisecond)
+			time.Sleep(150 * time.Millisecond)
+			r, _ = tb.Allow(ctx, "user:1")
+			t.Assert(r.Allowed, true)
+
+			// 并发请求不超过配额
+			fw = ratelimit.NewFixedWindow(c, 20, time.Hour)
+			var (
+				wg      sync.WaitGroup
+				allowed int64
+			)
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 5; j++ {
+						if r, err := fw.Allow(ctx, "concurrent"); err == nil && r.Allowed {
+							atomic.AddInt64(&allowed, 1)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			t.Assert(allowed, 20)
+			c.Clear(ctx)
+		})
+	}
+}
+
+func Test_RateLimitInvalidOptions(t *testing.T) {
+	c := cache.New("ratelimit_invalid_")
+	panics := func(f func()) (panicked bool) {
+		defer func() {
+			panicked = recover() != nil
+		}()
+		f()
+		return
+	}
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(panics(func() { ratelimit.NewFixedWindow(c, 0, time.Minute) }), true)
+		t.Assert(panics(func() { ratelimit.NewFixedWindow(c, 1, 0) }), true)
+		t.Assert(panics(func() { ratelimit.NewSlidingWindow(c, -1, time.Minute) }), true)
+		t.Assert(panics(func() { ratelimit.NewSlidingWindow(c, 1, -time.Second) }), true)
+		t.Assert(panics(func() { ratelimit.NewTokenBucket(c, 0, 1) }), true)
+		t.Assert(panics(func() { ratelimit.NewTokenBucket(c, math.NaN(), 1) }), true)
+		t.Assert(panics(func() { ratelimit.NewTokenBucket(c, 1, 0) }), true)
+		t.Assert(panics(func() { ratelimit.NewTokenBucket(c, 0.5, 1) }), false)
+	})
+}
+
+func Test_RateLimitTTLJitter(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		// TTL抖动不影响限流状态的过期时间，否则配额会在窗口中途重置
+		c := cache.NewDist("rl_jitter_").SetTTLJitter(0.9)
+		window := time.Hour
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("user:%d", i)
+			now := time.Now()
+			r, err := ratelimit.NewFixedWindow(c, 10, window).Allow(ctx, key)
+			t.AssertNil(err)
+			t.Assert(r.Allowed, true)
+			expire, err := adapter.NewDist().GetExpire(ctx, "rl_jitter___ratelimit:fw:"+key)
+			t.AssertNil(err)
+			// 状态保留到窗口结束后一秒，磁盘缓存的过期时间精确到秒
+			expected := now.Truncate(window).Add(window).Sub(now) + time.Second
+			t.AssertLE(expire, expected+time.Second)
+			t.AssertGE(expire, expected-time.Second)
+		}
+		c.Clear(ctx)
+	})
+}
+
+// conflictCache 比较并交换总是失败的缓存
+type conflictCache struct {
+	*cache.GfCache
+}
+
+func (conflictCache) CompareAndSetE(ctx context.Context, key string, expectedVersion uint64, value interface{}, ttl time.Duration) (bool, error) {
+	return false, nil
+}
+
+func Test_RateLimitContention(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		// 状态一直被其它请求修改时不会无限重试
+		fw := ratelimit.NewFixedWindow(conflictCache{cache.New("rl_conflict_")}, 10, time.Minute)
+		r, err := fw.Allow(ctx, "user:1")
+		t.AssertNil(r)
+		t.Assert(errors.Is(err, ratelimit.ErrContention), true)
+	})
+}
@@ -0,0 +1,158 @@
+/*
+* @desc:并发加载合并测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 18:50
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/adapter"
+)
+
+func Test_SingleflightLoad(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("singleflight_") {
+		gtest.C(t, func(t *gtest.T) {
+			var (
+				calls   int32
+				wg      sync.WaitGroup
+				loadErr = errors.New("load failed")
+			)
+			// 同一个键的并发加载只执行一次，错误共享给所有等待者
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := c.GetOrSetFuncE(ctx, "fail", func(ctx context.Context) (interface{}, error) {
+						atomic.AddInt32(&calls, 1)
+						time.Sleep(100 * time.Millisecond)
+						return nil, loadErr
+					}, 0)
+					t.Assert(errors.Is(err, loadErr), true)
+				}()
+			}
+			wg.Wait()
+			t.Assert(atomic.LoadInt32(&calls), 1)
+
+			calls = 0
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					v, err := c.GetOrSetFuncLockE(ctx, "ok", func(ctx context.Context) (interface{}, error) {
+						atomic.AddInt32(&calls, 1)
+						time.Sleep(100 * time.Millisecond)
+						return "v", nil
+					}, 0, "t")
+					t.AssertNil(err)
+					t.Assert(v, "v")
+				}()
+			}
+			wg.Wait()
+			t.Assert(atomic.LoadInt32(&calls), 1)
+			t.Assert(c.KeysByTag(ctx, "t"), []string{"ok"})
+		})
+		gtest.C(t, func(t *gtest.T) {
+			// 不同键的加载互不阻塞
+			started := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				c.GetOrSetFunc(ctx, "a", func(ctx context.Context) (interface{}, error) {
+					close(started)
+					time.Sleep(time.Second)
+					return 1, nil
+				}, 0)
+			}()
+			<-started
+			begin := time.Now()
+			c.GetOrSetFunc(ctx, "b", func(ctx context.Context) (interface{}, error) {
+				return 2, nil
+			}, 0)
+			t.Assert(time.Since(begin) < 500*time.Millisecond, true)
+			<-done
+		})
+	}
+}
+
+func Test_DistGetOrSetFuncLock(t *testing.T) {
+	ctx := context.Background()
+	d := adapter.NewDist()
+	gtest.C(t, func(t *gtest.T) {
+		var (
+			calls int32
+			wg    sync.WaitGroup
+		)
+		// 同一个键的并发加载只执行一次，只有一个调用写入
+		var stored int32
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := d.SetIfNotExistFuncLock(ctx, "dist_lock_key", func(ctx context.Context) (interface{}, error) {
+					atomic.AddInt32(&calls, 1)
+					time.Sleep(50 * time.Millisecond)
+					return "v", nil
+				}, 0)
+				t.AssertNil(err)
+				if ok {
+					atomic.AddInt32(&stored, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		t.Assert(atomic.LoadInt32(&calls), 1)
+		t.Assert(atomic.LoadInt32(&stored), 1)
+		v, err := d.GetOrSetFuncLock(ctx, "dist_lock_key", func(ctx context.Context) (interface{}, error) {
+			return "w", nil
+		}, 0)
+		t.AssertNil(err)
+		t.Assert(v.String(), "v")
+		_, err = d.Remove(ctx, "dist_lock_key")
+		t.AssertNil(err)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		// 慢加载不阻塞其它键的加载和写入
+		var (
+			started = make(chan struct{})
+			release = make(chan struct{})
+			done    = make(chan struct{})
+		)
+		go func() {
+			defer close(done)
+			v, err := d.GetOrSetFuncLock(ctx, "dist_lock_slow", func(ctx context.Context) (interface{}, error) {
+				close(started)
+				<-release
+				return "slow", nil
+			}, 0)
+			t.AssertNil(err)
+			t.Assert(v.String(), "slow")
+		}()
+		<-started
+		v, err := d.GetOrSetFuncLock(ctx, "dist_lock_fast", func(ctx context.Context) (interface{}, error) {
+			return "fast", nil
+		}, 0)
+		t.AssertNil(err)
+		t.Assert(v.String(), "fast")
+		t.AssertNil(d.Set(ctx, "dist_lock_other", "other", 0))
+		ok, err := d.SetIfNotExistFuncLock(ctx, "dist_lock_new", func(ctx context.Context) (interface{}, error) {
+			return "new", nil
+		}, 0)
+		t.AssertNil(err)
+		t.Assert(ok, true)
+		close(release)
+		<-done
+		_, err = d.Remove(ctx, "dist_lock_slow", "dist_lock_fast", "dist_lock_other", "dist_lock_new")
+		t.AssertNil(err)
+	})
+}
@@ -0,0 +1,97 @@
+/*
+* @desc:加载失败时返回过期值测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 20:10
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_StaleIfError(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		loadErr = errors.New("db timeout")
+		fail    = func(ctx context.Context) (interface{}, error) {
+			return nil, loadErr
+		}
+	)
+	for _, c := range newCaches("stale_") {
+		gtest.C(t, func(t *gtest.T) {
+			c.SetStaleIfError(time.Minute)
+			v, err := c.GetOrSetFuncE(ctx, "order", func(ctx context.Context) (interface{}, error) {
+				return 1, nil
+			}, 200*time.Millisecond, "t")
+			t.AssertNil(err)
+			t.Assert(v, 1)
+			t.Assert(c.GetOrSetFunc(ctx, "order", fail, 200*time.Millisecond), 1)
+
+			// 逻辑过期后普通读取不再返回
+			time.Sleep(300 * time.Millisecond)
+			t.AssertNil(c.Get(ctx, "order"))
+			t.Assert(len(c.Data(ctx)), 0)
+
+			// 加载失败时返回过期值并标记
+			v, err = c.GetOrSetFuncE(ctx, "order", fail, 200*time.Millisecond, "t")
+			t.Assert(v, 1)
+			t.Assert(errors.Is(err, cache.ErrStale), true)
+			t.Assert(errors.Is(err, loadErr), true)
+			t.Assert(c.GetOrSetFuncLock(ctx, "order", fail, 200*time.Millisecond), 1)
+
+			// 加载成功后更新
+			v, err = c.GetOrSetFuncE(ctx, "order", func(ctx context.Context) (interface{}, error) {
+				return 2, nil
+			}, 200*time.Millisecond, "t")
+			t.AssertNil(err)
+			t.Assert(v, 2)
+			t.Assert(c.Get(ctx, "order"), 2)
+
+			// 没有过期值时返回加载错误
+			v, err = c.GetOrSetFuncE(ctx, "missing", fail, 200*time.Millisecond)
+			t.AssertNil(v)
+			t.Assert(errors.Is(err, cache.ErrStale), false)
+			t.Assert(errors.Is(err, loadErr), true)
+			c.Clear(ctx)
+		})
+	}
+}
+
+func Test_StaleIfErrorWriteIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("stale_nx_")
+	caches["generation"] = cache.New("stale_nx_gen_").SetGeneration(true)
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			c.SetStaleIfError(time.Minute)
+			load := func(v interface{}) func(ctx context.Context) (interface{}, error) {
+				return func(ctx context.Context) (interface{}, error) {
+					return v, nil
+				}
+			}
+			c.GetOrSetFunc(ctx, "a", load("v1"), 200*time.Millisecond, "t")
+			c.GetOrSetFunc(ctx, "b", load("v1"), 200*time.Millisecond)
+			time.Sleep(300 * time.Millisecond)
+			t.Assert(c.Contains(ctx, "a"), false)
+
+			// 为加载失败保留的过期值视为不存在
+			t.Assert(c.GetOrSet(ctx, "a", "v2", time.Minute, "t"), "v2")
+			t.Assert(c.Get(ctx, "a"), "v2")
+			t.Assert(c.SetIfNotExist(ctx, "b", "v2", time.Minute), true)
+			t.Assert(c.Get(ctx, "b"), "v2")
+			// 未过期的值不覆盖
+			t.Assert(c.GetOrSet(ctx, "a", "v3", time.Minute), "v2")
+			t.Assert(c.SetIfNotExist(ctx, "b", "v3", time.Minute, "t"), false)
+			t.Assert(c.Get(ctx, "b"), "v2")
+			c.Clear(ctx)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+* @desc:过期后后台刷新测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 19:30
+ */
+
+package test
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_GetOrSetFuncSWR(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("swr_") {
+		gtest.C(t, func(t *gtest.T) {
+			var (
+				calls   int32
+				release = make(chan struct{})
+			)
+			load := func(ctx context.Context) (interface{}, error) {
+				if atomic.AddInt32(&calls, 1) > 1 {
+					<-release
+				}
+				return atomic.LoadInt32(&calls), nil
+			}
+			t.Assert(c.GetOrSetFuncSWR(ctx, "report", load, 300*time.Millisecond, 10*time.Second, "t"), 1)
+			// 新鲜期内不调用加载函数
+			t.Assert(c.GetOrSetFuncSWR(ctx, "report", load, 300*time.Millisecond, 10*time.Second, "t"), 1)
+			t.Assert(atomic.LoadInt32(&calls), 1)
+			// 元数据对普通读取透明
+			t.Assert(c.Get(ctx, "report"), 1)
+			t.Assert(c.Data(ctx), map[interface{}]interface{}{"report": 1})
+			t.Assert(c.KeysByTag(ctx, "t"), []string{"report"})
+
+			// 过期后立即返回旧值，后台刷新
+			time.Sleep(400 * time.Millisecond)
+			var (
+				begin      = time.Now()
+				goroutines = runtime.NumGoroutine()
+			)
+			for i := 0; i < 100; i++ {
+				t.Assert(c.GetOrSetFuncSWR(ctx, "report", load, 300*time.Millisecond, 10*time.Second, "t"), 1)
+			}
+			t.AssertLT(time.Since(begin), 500*time.Millisecond)
+			// 刷新未完成时不再启动新的刷新协程
+			t.AssertLT(runtime.NumGoroutine()-goroutines, 10)
+			close(release)
+			time.Sleep(200 * time.Millisecond)
+			t.Assert(atomic.LoadInt32(&calls), 2)
+			t.Assert(c.GetOrSetFuncSWR(ctx, "report", load, 300*time.Millisecond, 10*time.Second, "t"), 2)
+			t.Assert(c.Get(ctx, "report"), 2)
+
+			// 非法的新鲜期
+			_, err := c.GetOrSetFuncSWRE(ctx, "bad", load, 0, time.Second)
+			t.AssertNE(err, nil)
+			c.Clear(ctx)
+		})
+	}
+}
+
+func Test_GetOrSetFuncSWRGeneration(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewRedis("swr_gen_", testRedisName).SetGeneration(true)
+		tiered := cache.NewTiered("swr_tiered_", cache.L1Options{TTL: time.Minute}, c)
+		var calls int32
+		load := func(ctx context.Context) (interface{}, error) {
+			return atomic.AddInt32(&calls, 1), nil
+		}
+		t.Assert(tiered.GetOrSetFuncSWR(ctx, "report", load, 200*time.Millisecond, time.Minute, "t"), 1)
+		t.Assert(c.Get(ctx, "report"), 1)
+		// L1只缓存新鲜期
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(tiered.GetOrSetFuncSWR(ctx, "report", load, 200*time.Millisecond, time.Minute, "t"), 1)
+		time.Sleep(100 * time.Millisecond)
+		t.Assert(atomic.LoadInt32(&calls), 2)
+		t.Assert(c.Get(ctx, "report"), 2)
+		// 标签失效后重新同步加载
+		tiered.RemoveByTag(ctx, "t")
+		t.Assert(tiered.GetOrSetFuncSWR(ctx, "report", load, 200*time.Millisecond, time.Minute, "t"), 3)
+		c.Clear(ctx)
+	})
+}
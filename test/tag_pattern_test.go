@@ -0,0 +1,64 @@
+/*
+* @desc:层级标签测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 15:20
+ */
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gogf/gf/v2/test/gtest"
+)
+
+func Test_TagPattern(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("tag_pattern_") {
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "org1", 1, 0, "org:1")
+			c.Set(ctx, "dept3", 1, 0, "org:1/dept:3")
+			c.Set(ctx, "user9", 1, 0, "org:1/dept:3/user:9")
+			c.Set(ctx, "org10", 1, 0, "org:10")
+			c.Set(ctx, "org2", 1, 0, "org:2/dept:3")
+
+			// 子树包含根标签，不包含同前缀的兄弟标签
+			c.RemoveByTagPattern(ctx, "org:1/*")
+			t.Assert(c.Contains(ctx, "org1"), false)
+			t.Assert(c.Contains(ctx, "dept3"), false)
+			t.Assert(c.Contains(ctx, "user9"), false)
+			t.Assert(c.Contains(ctx, "org10"), true)
+			t.Assert(c.Contains(ctx, "org2"), true)
+			t.Assert(c.ListTags(ctx), []string{"org:10", "org:2/dept:3"})
+		})
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "a", 1, 0, "org:1/dept:3")
+			c.Set(ctx, "b", 1, 0, "org:2/dept:3/user:1")
+			c.Set(ctx, "c", 1, 0, "org:2/dept:4")
+
+			// * 不跨越层级
+			c.RemoveByTagPattern(ctx, "org:*/dept:3")
+			t.Assert(c.Contains(ctx, "a"), false)
+			t.Assert(c.Contains(ctx, "b"), true)
+			t.Assert(c.Contains(ctx, "c"), true)
+
+			c.RemoveByTagPattern(ctx, "org:*/*")
+			t.Assert(c.Contains(ctx, "b"), false)
+			t.Assert(c.Contains(ctx, "c"), false)
+
+			t.AssertNE(c.RemoveByTagPatternE(ctx, "org:[/*"), nil)
+		})
+		gtest.C(t, func(t *gtest.T) {
+			// RemoveByTag 按字面删除包含通配符的标签
+			c.Set(ctx, "star", 1, 0, "sku:*")
+			c.Set(ctx, "sku1", 1, 0, "sku:1")
+			t.AssertNil(c.RemoveByTagE(ctx, "sku:*"))
+			t.Assert(c.Contains(ctx, "star"), false)
+			t.Assert(c.Contains(ctx, "sku1"), true)
+			t.AssertNil(c.RemoveByTagE(ctx, "sku:["))
+			t.AssertNil(c.ClearE(ctx))
+		})
+	}
+}
@@ -0,0 +1,65 @@
+/*
+* @desc:缓存标签查询测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 14:40
+ */
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_TagQuery(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("tag_query_") {
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "user42", "u42", 0, "user:42", "dept:7")
+			c.Set(ctx, "user43", "u43", 0, "user:43", "dept:7")
+			c.Set(ctx, "plain", 1, 0)
+
+			t.Assert(c.ListTags(ctx), []string{"dept:7", "user:42", "user:43"})
+			t.Assert(c.KeysByTag(ctx, "dept:7"), []string{"user42", "user43"})
+			t.Assert(c.TagSize(ctx, "dept:7"), 2)
+			t.Assert(c.TagsOfKey(ctx, "user42"), []string{"dept:7", "user:42"})
+			t.Assert(c.TagsOfKey(ctx, "plain"), []string{})
+			t.Assert(c.KeysByTag(ctx, "none"), []string{})
+			t.Assert(c.TagSize(ctx, "none"), 0)
+
+			// 删除缓存时同时清理标签索引
+			c.Remove(ctx, "user42")
+			t.Assert(c.KeysByTag(ctx, "dept:7"), []string{"user43"})
+			t.Assert(c.TagsOfKey(ctx, "user42"), []string{})
+			t.Assert(c.ListTags(ctx), []string{"dept:7", "user:43"})
+
+			c.Removes(ctx, []string{"user43", "plain"})
+			t.Assert(c.TagSize(ctx, "dept:7"), 0)
+			t.Assert(c.ListTags(ctx), []string{})
+		})
+	}
+}
+
+// 标签索引前缀下的普通缓存不是标签
+func Test_TagQueryUserKey(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("tag_query_user_")
+	caches["generation"] = cache.New("tag_query_user_gen_").SetGeneration(true)
+	for _, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "tag_user_key", "v", 0)
+			c.Set(ctx, "user42", "u42", 0, "dept:7")
+
+			t.Assert(c.ListTags(ctx), []string{"dept:7"})
+			c.RemoveByTagPattern(ctx, "*")
+			t.Assert(c.ListTags(ctx), []string{})
+			t.Assert(c.Contains(ctx, "user42"), false)
+			t.Assert(c.Get(ctx, "tag_user_key"), "v")
+			c.Clear(ctx)
+		})
+	}
+}
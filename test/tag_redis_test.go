@@ -0,0 +1,70 @@
+/*
+* @desc:redis缓存标签原子性测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 12:10
+ */
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_RedisTagIndex(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewRedis("redis_tag_concurrent_", testRedisName)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				c.Set(ctx, fmt.Sprintf("k%d", i), i, 0, "hot", fmt.Sprintf("k:%d", i))
+			}(i)
+		}
+		wg.Wait()
+		members, err := redisServer.ZMembers("redis_tag_concurrent_tag_hot")
+		t.AssertNil(err)
+		t.Assert(len(members), 50)
+
+		c.RemoveByTag(ctx, "hot")
+		for i := 0; i < 50; i++ {
+			t.Assert(c.Contains(ctx, fmt.Sprintf("k%d", i)), false)
+			t.Assert(redisServer.Exists(fmt.Sprintf("redis_tag_concurrent_tag_k:%d", i)), false)
+		}
+		t.Assert(redisServer.Exists("redis_tag_concurrent_tag_hot"), false)
+	})
+	// 兼容旧版本的json数组标签索引
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewRedis("redis_tag_legacy_", testRedisName)
+		c.Set(ctx, "a", 1, 0)
+		c.Set(ctx, "b", 2, 0)
+		t.AssertNil(redisServer.Set("redis_tag_legacy_tag_old", `["a","b"]`))
+		c.Set(ctx, "c", 3, 0, "old")
+		members, err := redisServer.ZMembers("redis_tag_legacy_tag_old")
+		t.AssertNil(err)
+		t.Assert(members, []string{"a", "b", "c"})
+		c.RemoveByTag(ctx, "old")
+		t.Assert(c.Contains(ctx, "a"), false)
+		t.Assert(c.Contains(ctx, "b"), false)
+		t.Assert(c.Contains(ctx, "c"), false)
+	})
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewRedis("redis_tag_nx_", testRedisName)
+		t.Assert(c.SetIfNotExist(ctx, "k", "v1", 0, "t"), true)
+		t.Assert(c.SetIfNotExist(ctx, "k", "v2", 0, "t"), false)
+		t.Assert(c.GetOrSet(ctx, "k", "v3", 0, "t").String(), "v1")
+		t.Assert(c.GetOrSet(ctx, "k2", "v4", 0, "t").String(), "v4")
+		t.Assert(c.Get(ctx, "k2").String(), "v4")
+		c.RemoveByTag(ctx, "t")
+		t.Assert(c.Contains(ctx, "k"), false)
+		t.Assert(c.Contains(ctx, "k2"), false)
+	})
+}
@@ -0,0 +1,154 @@
+/*
+* @desc:缓存标签测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 11:20
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcache"
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_MultipleTags(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("multi_tags_") {
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "user42", "u42", 0, "user:42", "dept:7")
+			c.Set(ctx, "user43", "u43", 0, "user:43", "dept:7")
+			c.SetIfNotExist(ctx, "user44", "u44", 0, "user:44", "dept:8")
+			c.GetOrSet(ctx, "user45", "u45", 0, "user:45", "dept:8")
+			c.GetOrSetFunc(ctx, "user46", func(ctx context.Context) (interface{}, error) {
+				return "u46", nil
+			}, 0, "user:46", "dept:8")
+			var f gcache.Func = func(ctx context.Context) (interface{}, error) {
+				return "u47", nil
+			}
+			c.GetOrSetFuncLock(ctx, "user47", f, 0, "user:47", "dept:8")
+
+			// 任一标签均可删除
+			c.RemoveByTag(ctx, "user:42")
+			t.Assert(c.Contains(ctx, "user42"), false)
+			t.Assert(c.Contains(ctx, "user43"), true)
+			c.RemoveByTag(ctx, "dept:7")
+			t.Assert(c.Contains(ctx, "user43"), false)
+
+			c.RemoveByTag(ctx, "dept:8")
+			for _, key := range []string{"user44", "user45", "user46", "user47"} {
+				t.Assert(c.Contains(ctx, key), false)
+			}
+			// 其它标签中的索引已被清理
+			t.Assert(c.Contains(ctx, "tag_user:43"), false)
+			t.Assert(c.Contains(ctx, "tag_user:47"), false)
+		})
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "a", 1, 0, "t1", "t2")
+			c.Set(ctx, "b", 2, 0, "t2")
+			c.RemoveByTag(ctx, "t1")
+			t.Assert(c.Contains(ctx, "a"), false)
+			t.Assert(c.Contains(ctx, "b"), true)
+			// t2 中只剩 b
+			c.Set(ctx, "a", 3, 0)
+			c.RemoveByTag(ctx, "t2")
+			t.Assert(c.Contains(ctx, "a"), true)
+			t.Assert(c.Contains(ctx, "b"), false)
+		})
+	}
+}
+
+func Test_EmptyTag(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("empty_tag_") {
+		gtest.C(t, func(t *gtest.T) {
+			// 空标签不登记，不会写入与缓存前缀同名的索引
+			c.Set(ctx, "a", "1", 0, "")
+			c.Set(ctx, "b", "2", 0, "", "t")
+			t.Assert(c.ListTags(ctx), []string{"t"})
+			t.Assert(c.TagsOfKey(ctx, "a"), []string{})
+			t.Assert(c.TagsOfKey(ctx, "b"), []string{"t"})
+			c.RemoveByTag(ctx, "")
+			t.Assert(c.Get(ctx, "a"), "1")
+			t.Assert(c.Get(ctx, "b"), "2")
+			t.AssertNil(c.ClearE(ctx))
+		})
+	}
+}
+
+func Test_CompactTags(t *testing.T) {
+	ctx := context.Background()
+	caches := newCaches("compact_tags_")
+	for _, c := range caches {
+		c.Set(ctx, "short", 1, time.Second, "t", "short")
+		c.Set(ctx, "long", 2, 0, "t")
+	}
+	time.Sleep(1100 * time.Millisecond)
+	for name, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			t.AssertNil(c.CompactTags(ctx))
+			t.Assert(tagMembers(name, c, "t"), []string{"long"})
+			t.Assert(tagMembers(name, c, "short"), []string{})
+			// 写入时清理已过期的成员
+			c.Set(ctx, "short2", 3, time.Second, "t2")
+			c.Set(ctx, "long2", 4, 0, "t2")
+		})
+	}
+	time.Sleep(1100 * time.Millisecond)
+	for name, c := range caches {
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "long3", 5, 0, "t2")
+			t.Assert(tagMembers(name, c, "t2"), []string{"long2", "long3"})
+		})
+	}
+	gtest.C(t, func(t *gtest.T) {
+		t.Assert(errors.Is(caches["memory"].StartCompactTags(ctx, 0), cache.ErrInvalidInterval), true)
+		t.AssertNil(caches["memory"].StartCompactTags(ctx, time.Hour))
+	})
+}
+
+func Test_CompactTagsUserKeys(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("compact_user_") {
+		gtest.C(t, func(t *gtest.T) {
+			// 键名与标签索引前缀相同的普通缓存不被当作索引处理
+			c.Set(ctx, "tag_config", map[string]interface{}{"a": 1, "b": 2}, 0)
+			c.Set(ctx, "k", "v", 0, "t")
+			t.AssertNil(c.CompactTags(ctx))
+			t.Assert(c.Get(ctx, "tag_config").Map(), g.Map{"a": 1, "b": 2})
+			t.Assert(c.KeysByTag(ctx, "t"), []string{"k"})
+			t.AssertNil(c.ClearE(ctx))
+		})
+	}
+}
+
+// 读取标签索引中的成员
+func tagMembers(name string, c *cache.GfCache, tag string) []string {
+	var members []string
+	if name == "redis" {
+		members, _ = redisServer.ZMembers(c.CachePrefix + "tag_" + tag)
+	} else {
+		idx := gconv.Map(c.Get(context.Background(), "tag_"+tag))
+		// 磁盘缓存的索引带有标记
+		if inner, ok := idx["__gfcache_tags"]; ok {
+			idx = gconv.Map(inner)
+		}
+		for member := range idx {
+			members = append(members, member)
+		}
+	}
+	if members == nil {
+		members = []string{}
+	}
+	sort.Strings(members)
+	return members
+}
@@ -0,0 +1,86 @@
+/*
+* @desc:二级缓存测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 17:20
+ */
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_Tiered(t *testing.T) {
+	ctx := context.Background()
+	l2s := map[string]*cache.GfCache{
+		"redis": cache.NewRedis("tiered_", testRedisName),
+		"dist":  cache.NewDist("tiered_"),
+	}
+	for name, l2 := range l2s {
+		c := cache.NewTiered("tiered_"+name, cache.L1Options{Size: 100, TTL: 200 * time.Millisecond}, l2)
+		gtest.C(t, func(t *gtest.T) {
+			c.Set(ctx, "a", "1", 0, "t")
+			t.Assert(c.Get(ctx, "a"), "1")
+			// 直接修改L2，L1过期前仍读取L1
+			l2.Set(ctx, "a", "2", 0, "t")
+			t.Assert(c.Get(ctx, "a"), "1")
+			time.Sleep(300 * time.Millisecond)
+			t.Assert(c.Get(ctx, "a"), "2")
+
+			// 读取时提升到L1
+			l2.Set(ctx, "b", "1", 0)
+			t.Assert(c.Get(ctx, "b"), "1")
+			l2.Set(ctx, "b", "2", 0)
+			t.Assert(c.Get(ctx, "b"), "1")
+			t.Assert(c.Remove(ctx, "b"), "2")
+			t.Assert(c.Contains(ctx, "b"), false)
+			t.Assert(l2.Contains(ctx, "b"), false)
+
+			// 删除标签时清空L1
+			t.Assert(c.Get(ctx, "a"), "2")
+			c.RemoveByTag(ctx, "t")
+			t.Assert(c.Contains(ctx, "a"), false)
+			t.Assert(l2.Contains(ctx, "a"), false)
+
+			v := c.GetOrSetFunc(ctx, "c", func(ctx context.Context) (interface{}, error) {
+				return "3", nil
+			}, 0, "t")
+			t.Assert(v, "3")
+			t.Assert(c.GetOrSet(ctx, "c", "4", 0), "3")
+			t.Assert(c.SetIfNotExist(ctx, "c", "4", 0), false)
+			t.Assert(c.KeyStrings(ctx), []string{"c"})
+			t.Assert(c.KeysByTag(ctx, "t"), []string{"c"})
+			c.Clear(ctx)
+			t.Assert(c.Contains(ctx, "c"), false)
+			t.Assert(c.Size(ctx), 0)
+		})
+	}
+}
+
+// 从L2读取的值在L1中不晚于L2过期
+func Test_TieredL2TTL(t *testing.T) {
+	ctx := context.Background()
+	l2s := map[string]*cache.GfCache{
+		"memory": cache.New("tiered_ttl_"),
+		"stale":  cache.New("tiered_ttl_stale_").SetStaleIfError(time.Minute),
+	}
+	for name, l2 := range l2s {
+		c := cache.NewTiered("tiered_ttl_"+name, cache.L1Options{TTL: time.Minute}, l2)
+		gtest.C(t, func(t *gtest.T) {
+			l2.Set(ctx, "a", "1", 200*time.Millisecond)
+			l2.Set(ctx, "b", "2", 0)
+			t.Assert(c.Get(ctx, "a"), "1")
+			t.Assert(c.Get(ctx, "b"), "2")
+			time.Sleep(300 * time.Millisecond)
+			t.AssertNil(c.Get(ctx, "a"))
+			t.Assert(c.Get(ctx, "b"), "2")
+			c.Clear(ctx)
+		})
+	}
+}
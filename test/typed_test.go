@@ -0,0 +1,112 @@
+/*
+* @desc:类型化缓存测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 9:45
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+type typedUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func Test_Typed(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("typed_") {
+		gtest.C(t, func(t *gtest.T) {
+			users := cache.NewTyped[*typedUser](c)
+			_, ok, err := users.Get(ctx, "none")
+			t.AssertNil(err)
+			t.Assert(ok, false)
+
+			t.AssertNil(users.Set(ctx, "u1", &typedUser{Name: "zhangsan", Age: 10}, 0))
+			u, ok, err := users.Get(ctx, "u1")
+			t.AssertNil(err)
+			t.Assert(ok, true)
+			t.Assert(u.Name, "zhangsan")
+			t.Assert(u.Age, 10)
+
+			// 以 map 写入，按结构体读取
+			c.Set(ctx, "u2", g.Map{"name": "lisi", "age": 20}, 0)
+			structs := cache.NewTyped[typedUser](c)
+			v, ok, err := structs.Get(ctx, "u2")
+			t.AssertNil(err)
+			t.Assert(ok, true)
+			t.Assert(v, typedUser{Name: "lisi", Age: 20})
+		})
+		gtest.C(t, func(t *gtest.T) {
+			ints := cache.NewTyped[int](c)
+			t.AssertNil(ints.Set(ctx, "n", 42, 0))
+			n, ok, err := ints.Get(ctx, "n")
+			t.AssertNil(err)
+			t.Assert(ok, true)
+			t.Assert(n, 42)
+
+			strs := cache.NewTyped[[]string](c)
+			t.AssertNil(strs.Set(ctx, "s", []string{"a", "b"}, 0))
+			s, ok, err := strs.Get(ctx, "s")
+			t.AssertNil(err)
+			t.Assert(ok, true)
+			t.Assert(s, []string{"a", "b"})
+
+			bools := cache.NewTyped[bool](c)
+			t.AssertNil(bools.Set(ctx, "b", true, 0))
+			b, ok, err := bools.Get(ctx, "b")
+			t.AssertNil(err)
+			t.Assert(ok, true)
+			t.Assert(b, true)
+		})
+		gtest.C(t, func(t *gtest.T) {
+			users := cache.NewTyped[typedUser](c)
+			calls := 0
+			loader := func(ctx context.Context) (typedUser, error) {
+				calls++
+				return typedUser{Name: "wangwu", Age: 30}, nil
+			}
+			u, err := users.GetOrSetFunc(ctx, "u3", loader, 0)
+			t.AssertNil(err)
+			t.Assert(u.Name, "wangwu")
+			u, err = users.GetOrSetFunc(ctx, "u3", loader, 0)
+			t.AssertNil(err)
+			t.Assert(u.Age, 30)
+			t.Assert(calls, 1)
+
+			loadErr := errors.New("db down")
+			_, err = users.GetOrSetFunc(ctx, "u4", func(ctx context.Context) (typedUser, error) {
+				return typedUser{}, loadErr
+			}, 0)
+			t.Assert(errors.Is(err, loadErr), true)
+			t.Assert(c.Contains(ctx, "u4"), false)
+		})
+	}
+}
+
+func Test_TypedString(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("typed_string_") {
+		gtest.C(t, func(t *gtest.T) {
+			// 看起来像json的字符串在所有适配器上原样返回
+			strs := cache.NewTyped[string](c)
+			for _, s := range []string{`"quoted"`, `{"a":1}`, `[1,2]`, `123`, `plain`} {
+				t.AssertNil(strs.Set(ctx, "s", s, 0))
+				v, ok, err := strs.Get(ctx, "s")
+				t.AssertNil(err)
+				t.Assert(ok, true)
+				t.Assert(v, s)
+			}
+			c.Clear(ctx)
+		})
+	}
+}
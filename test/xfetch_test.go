@@ -0,0 +1,73 @@
+/*
+* @desc:提前过期及过期时间随机浮动测试
+* @company:云南奇讯科技有限公司
+* @Author: yixiaohu
+* @Date:   2026/10/16 20:50
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogf/gf/v2/test/gtest"
+	"github.com/tiger1103/gfast-cache/cache"
+)
+
+func Test_EarlyExpiration(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range newCaches("xfetch_") {
+		gtest.C(t, func(t *gtest.T) {
+			var calls int32
+			load := func(ctx context.Context) (interface{}, error) {
+				time.Sleep(50 * time.Millisecond)
+				return atomic.AddInt32(&calls, 1), nil
+			}
+			// 耗时较长的加载函数在过期前被重算
+			c.SetEarlyExpiration(1e6)
+			t.Assert(c.GetOrSetFunc(ctx, "k", load, time.Minute), 1)
+			t.Assert(c.GetOrSetFunc(ctx, "k", load, time.Minute), 2)
+			t.Assert(c.Get(ctx, "k"), 2)
+
+			// 重算失败时返回未过期的值
+			v, err := c.GetOrSetFuncE(ctx, "k", func(ctx context.Context) (interface{}, error) {
+				time.Sleep(50 * time.Millisecond)
+				return nil, errors.New("db timeout")
+			}, time.Minute)
+			t.AssertNil(err)
+			t.Assert(v, 2)
+
+			// 关闭后不再提前重算
+			c.SetEarlyExpiration(0)
+			t.Assert(c.GetOrSetFunc(ctx, "k", load, time.Minute), 2)
+			t.Assert(atomic.LoadInt32(&calls), 2)
+			c.Clear(ctx)
+		})
+	}
+}
+
+func Test_TTLJitter(t *testing.T) {
+	ctx := context.Background()
+	gtest.C(t, func(t *gtest.T) {
+		c := cache.NewRedis("jitter_", testRedisName).SetTTLJitter(0.2)
+		ttls := make(map[time.Duration]struct{})
+		for i := 0; i < 20; i++ {
+			key := fmt.Sprintf("k%d", i)
+			c.Set(ctx, key, i, 100*time.Second)
+			ttl := redisServer.TTL("jitter_" + key)
+			t.AssertGE(ttl, 80*time.Second)
+			t.AssertLE(ttl, 120*time.Second)
+			ttls[ttl] = struct{}{}
+		}
+		t.AssertGT(len(ttls), 1)
+		c.SetTTLJitter(0)
+		c.Set(ctx, "fixed", 1, 100*time.Second)
+		t.Assert(redisServer.TTL("jitter_fixed"), 100*time.Second)
+		c.Clear(ctx)
+	})
+}